@@ -45,6 +45,7 @@ func main() {
 		bootstrap.BuildValidator(),
 		bootstrap.ConfigModule(),
 		bootstrap.ServerModule,
+		bootstrap.JobModule,
 		bootstrap.RouterModule,
 	)
 
@@ -12,30 +12,35 @@ func SeedProducts(db *gorm.DB, categories []*model.Category, images []*model.Ima
 	products := []*model.Product{
 		{
 			Name:        "Smartphone X",
+			Slug:        "smartphone-x",
 			Description: &desc,
 			Price:       999000,
 			CategoryID:  categories[0].ID,
 		},
 		{
 			Name:        "Laptop Pro",
+			Slug:        "laptop-pro",
 			Description: &desc,
 			Price:       1500000,
 			CategoryID:  categories[0].ID,
 		},
 		{
 			Name:        "T-Shirt Basic",
+			Slug:        "t-shirt-basic",
 			Description: &desc,
 			Price:       150000,
 			CategoryID:  categories[1].ID,
 		},
 		{
 			Name:        "Jeans Classic",
+			Slug:        "jeans-classic",
 			Description: &desc,
 			Price:       300000,
 			CategoryID:  categories[1].ID,
 		},
 		{
 			Name:        "Go Programming",
+			Slug:        "go-programming",
 			Description: &desc,
 			Price:       250000,
 			CategoryID:  categories[2].ID,
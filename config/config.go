@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -19,6 +21,15 @@ type Config struct {
 	ZaloAppPrivateKey  string
 	ZaloAppSecret      string
 	WebhookApiKey      string
+	JobWorkers         int
+	// ZaloPaymentEnv is "production" or "sandbox"; sandbox uses Zalo's test
+	// payment methods.
+	ZaloPaymentEnv     string
+	ZaloPaymentBaseURL string
+	ZaloPaymentTimeout time.Duration
+	BankBIN            string
+	BankAccountNumber  string
+	BankAccountName    string
 }
 
 var AppConfig *Config
@@ -37,6 +48,13 @@ func InitConfig() *Config {
 		ZaloAppID:          getEnv("ZALO_APP_ID", ""),
 		ZaloAppSecret:      getEnv("ZALO_APP_SECRET", ""),
 		WebhookApiKey:      getEnv("WEBHOOK_API_KEY", ""),
+		JobWorkers:         getEnvInt("JOB_WORKERS", 2),
+		ZaloPaymentEnv:     getEnv("ZALO_PAYMENT_ENV", "production"),
+		ZaloPaymentBaseURL: getEnv("ZALO_PAYMENT_BASE_URL", "https://payment-mini.zalo.me/api"),
+		ZaloPaymentTimeout: getEnvDuration("ZALO_PAYMENT_TIMEOUT", 10*time.Second),
+		BankBIN:            getEnv("BANK_BIN", ""),
+		BankAccountNumber:  getEnv("BANK_ACCOUNT_NUMBER", ""),
+		BankAccountName:    getEnv("BANK_ACCOUNT_NAME", ""),
 	}
 
 	return AppConfig
@@ -62,6 +80,27 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// IsZaloPaymentSandbox reports whether payments go through Zalo's sandbox.
+func (c *Config) IsZaloPaymentSandbox() bool {
+	return c.ZaloPaymentEnv == "sandbox"
+}
+
 func IsProdEnv() bool {
 	return AppConfig.Mode == "production"
 }
@@ -12,7 +12,11 @@ func BuildController() fx.Option {
 		fx.Provide(controllers.NewBaseController),
 		fx.Provide(controllers.NewFolderController),
 		fx.Provide(controllers.NewOrderController),
+		fx.Provide(controllers.NewCartController),
+		fx.Provide(controllers.NewVoucherController),
+		fx.Provide(controllers.NewShippingController),
 		fx.Provide(controllers.NewPaymentController),
+		fx.Provide(controllers.NewReconciliationController),
 		fx.Provide(controllers.NewProductController),
 		fx.Provide(controllers.NewImageController),
 		fx.Provide(controllers.NewCategoryController),
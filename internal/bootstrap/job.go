@@ -0,0 +1,31 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/services"
+	"go.uber.org/fx"
+)
+
+// JobModule runs the background job workers for the lifetime of the app.
+// The handlers services provide in the job_handlers group are registered
+// before the workers start.
+var JobModule = fx.Module("jobs",
+	fx.Invoke(fx.Annotate(func(lc fx.Lifecycle, queue *services.JobQueue, handlers []services.JobHandlers) {
+		for _, group := range handlers {
+			for jobType, handler := range group {
+				queue.Register(jobType, handler)
+			}
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				queue.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				return queue.Stop(ctx)
+			},
+		})
+	}, fx.ParamTags(``, ``, `group:"job_handlers"`))),
+)
@@ -13,5 +13,10 @@ func BuildRepository() fx.Option {
 		repositories.NewFolderRepository,
 		repositories.NewCategoryRepository,
 		repositories.NewOrderRepository,
+		repositories.NewCartRepository,
+		repositories.NewVoucherRepository,
+		repositories.NewShippingRuleRepository,
+		repositories.NewJobRepository,
+		repositories.NewBankTransactionRepository,
 	)
 }
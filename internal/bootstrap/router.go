@@ -17,7 +17,11 @@ func registerRoutes(
 	categoryController *controllers.CategoryController,
 	authController *controllers.AuthController,
 	orderController *controllers.OrderController,
+	cartController *controllers.CartController,
+	voucherController *controllers.VoucherController,
+	shippingController *controllers.ShippingController,
 	paymentController *controllers.PaymentController,
+	reconciliationController *controllers.ReconciliationController,
 ) {
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "pong"})
@@ -29,7 +33,11 @@ func registerRoutes(
 	categoryController.RegisterRoutes(r)
 	authController.RegisterRoutes(r)
 	orderController.RegisterRoutes(r)
+	cartController.RegisterRoutes(r)
+	voucherController.RegisterRoutes(r)
+	shippingController.RegisterRoutes(r)
 	paymentController.RegisterRoutes(r)
+	reconciliationController.RegisterRoutes(r)
 }
 
 var RouterModule = fx.Options(
@@ -15,5 +15,31 @@ func BuildService() fx.Option {
 		services.NewAuthService,
 		services.NewOrderService,
 		services.NewPaymentService,
+		services.NewCartService,
+		services.NewVoucherService,
+		services.NewShippingService,
+		services.NewJobQueue,
+		services.NewReconciliationService,
+		services.NewZaloCheckoutProvider,
+		asPaymentProvider(services.NewBankTransferProvider),
+		asPaymentProvider(services.NewCodProvider),
+		fx.Annotate(services.NewPaymentProviders, fx.ParamTags(`group:"payment_providers"`)),
+		asJobHandlers((*services.PaymentService).JobHandlers),
+		asJobHandlers((*services.ProductService).JobHandlers),
+	)
+}
+
+// asJobHandlers registers the job handlers returned by handlers for the
+// queue to run.
+func asJobHandlers(handlers any) any {
+	return fx.Annotate(handlers, fx.ResultTags(`group:"job_handlers"`))
+}
+
+// asPaymentProvider registers the provider built by constructor for the
+// payment method it handles.
+func asPaymentProvider(constructor any) any {
+	return fx.Annotate(constructor,
+		fx.As(new(services.PaymentProvider)),
+		fx.ResultTags(`group:"payment_providers"`),
 	)
 }
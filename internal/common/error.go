@@ -23,12 +23,15 @@ type ErrorResponse struct {
 
 const (
 	//internal
-	ErrorCodeBadRequest   CodeResponse = "BAD_REQUEST"
-	ErrorCodeUnauthorized CodeResponse = "UNAUTHORIZED"
-	ErrorCodeForbidden    CodeResponse = "FORBIDDEN"
-	ErrorCodeNotFound     CodeResponse = "NOT_FOUND"
-	ErrorCodeConflict     CodeResponse = "CONFLICT"
-	ErrorCodeSystemError  CodeResponse = "INTERNAL_SERVER_ERROR"
+	ErrorCodeBadRequest           CodeResponse = "BAD_REQUEST"
+	ErrorCodeUnauthorized         CodeResponse = "UNAUTHORIZED"
+	ErrorCodeForbidden            CodeResponse = "FORBIDDEN"
+	ErrorCodeNotFound             CodeResponse = "NOT_FOUND"
+	ErrorCodeConflict             CodeResponse = "CONFLICT"
+	ErrorCodeOutOfStock           CodeResponse = "OUT_OF_STOCK"
+	ErrorCodeInvalidTransition    CodeResponse = "INVALID_STATUS_TRANSITION"
+	ErrorCodeVoucherNotApplicable CodeResponse = "VOUCHER_NOT_APPLICABLE"
+	ErrorCodeSystemError          CodeResponse = "INTERNAL_SERVER_ERROR"
 )
 
 type Source string
@@ -146,6 +149,41 @@ var (
 		}
 	}
 
+	ErrOutOfStock = func(ctx context.Context, item string, available int64) *Error {
+		traceId := GetTraceId(ctx)
+		return &Error{
+			Code:       ErrorCodeOutOfStock,
+			Message:    getMsg(item, "is out of stock"),
+			TraceID:    traceId,
+			Detail:     fmt.Sprintf("only %d left in stock", available),
+			HTTPStatus: http.StatusConflict,
+			Source:     CurrentService,
+		}
+	}
+
+	ErrInvalidTransition = func(ctx context.Context, object, from, to string) *Error {
+		traceId := GetTraceId(ctx)
+		return &Error{
+			Code:       ErrorCodeInvalidTransition,
+			Message:    getMsg(object, fmt.Sprintf("cannot move from %s to %s", from, to)),
+			TraceID:    traceId,
+			HTTPStatus: http.StatusConflict,
+			Source:     CurrentService,
+		}
+	}
+
+	ErrVoucherNotApplicable = func(ctx context.Context, code, reason string) *Error {
+		traceId := GetTraceId(ctx)
+		return &Error{
+			Code:       ErrorCodeVoucherNotApplicable,
+			Message:    getMsg("Voucher "+code, "cannot be applied"),
+			TraceID:    traceId,
+			Detail:     reason,
+			HTTPStatus: http.StatusUnprocessableEntity,
+			Source:     CurrentService,
+		}
+	}
+
 	// Status 5xx *******
 
 	ErrSystemError = func(ctx context.Context, detail string) *Error {
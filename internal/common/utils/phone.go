@@ -0,0 +1,36 @@
+package utils
+
+import "strings"
+
+// PhoneVariants returns the ways a Vietnamese phone number may have been
+// stored: local (0xxx), international (84xxx) and with a plus sign (+84xxx).
+func PhoneVariants(phone string) []string {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return nil
+	}
+
+	local := phone
+	switch {
+	case strings.HasPrefix(phone, "+84"):
+		local = "0" + strings.TrimPrefix(phone, "+84")
+	case strings.HasPrefix(phone, "84"):
+		local = "0" + strings.TrimPrefix(phone, "84")
+	}
+
+	if !strings.HasPrefix(local, "0") {
+		return []string{phone}
+	}
+	national := strings.TrimPrefix(local, "0")
+	return []string{local, "84" + national, "+84" + national}
+}
+
+// LocalPhone returns the phone number in local form (0xxx), the first of its
+// PhoneVariants.
+func LocalPhone(phone string) string {
+	variants := PhoneVariants(phone)
+	if len(variants) == 0 {
+		return ""
+	}
+	return variants[0]
+}
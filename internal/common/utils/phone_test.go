@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPhoneVariants(t *testing.T) {
+	want := []string{"0912345678", "84912345678", "+84912345678"}
+	for _, phone := range []string{"0912345678", "84912345678", "+84912345678", " 0912345678 "} {
+		if got := PhoneVariants(phone); !reflect.DeepEqual(got, want) {
+			t.Errorf("PhoneVariants(%q) = %v; want %v", phone, got, want)
+		}
+	}
+
+	if got := PhoneVariants(""); got != nil {
+		t.Errorf("PhoneVariants(\"\") = %v; want nil", got)
+	}
+}
+
+func TestLocalPhone(t *testing.T) {
+	if got := LocalPhone("+84912345678"); got != "0912345678" {
+		t.Errorf("LocalPhone(+84912345678) = %q; want 0912345678", got)
+	}
+}
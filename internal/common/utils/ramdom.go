@@ -4,11 +4,17 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"regexp"
+	"strings"
 	"time"
 )
 
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
+// orderIDCharset is upper-case only, so an order ID survives banks changing
+// the case of transfer content.
+const orderIDCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
 func GenerateOrderID(length int) string {
 	b := make([]byte, length)
 	for i := range b {
@@ -24,9 +30,37 @@ func GenerateUniqueOrderID() string {
 
 	b := make([]byte, 8)
 	for i := range b {
-		num, _ := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		b[i] = charset[num.Int64()]
+		num, _ := rand.Int(rand.Reader, big.NewInt(int64(len(orderIDCharset))))
+		b[i] = orderIDCharset[num.Int64()]
 	}
 
 	return fmt.Sprintf("NL%s%s", timestamp, string(b))
 }
+
+// orderIDPattern matches IDs made by GenerateUniqueOrderID. Banks may change
+// the case of transfer content, so it matches case-insensitively.
+var orderIDPattern = regexp.MustCompile(`(?i)NL\d{14}[A-Za-z0-9]{8}`)
+
+// ExtractOrderID finds the first order ID in free text such as bank transfer
+// content, tolerating prefixes, extra words and lowercasing. It returns the ID
+// with the NL prefix upper-cased, or "" when there is none; see
+// OrderIDCandidates for looking it up.
+func ExtractOrderID(content string) string {
+	match := orderIDPattern.FindString(content)
+	if match == "" {
+		return ""
+	}
+	return strings.ToUpper(match[:2]) + match[2:]
+}
+
+// OrderIDCandidates lists the stored IDs an ID found by ExtractOrderID may
+// stand for, most likely first: the ID as written, which is how orders made
+// before IDs were upper case are matched, then upper-cased as
+// GenerateUniqueOrderID makes them now.
+func OrderIDCandidates(id string) []string {
+	upper := strings.ToUpper(id)
+	if upper == id {
+		return []string{id}
+	}
+	return []string{id, upper}
+}
@@ -24,6 +24,9 @@ func TestGenerateUniqueOrderID(t *testing.T) {
 	if len(id) != 24 {
 		t.Errorf("GenerateUniqueOrderID() length = %d; want 24", len(id))
 	}
+	if id != strings.ToUpper(id) {
+		t.Errorf("GenerateUniqueOrderID() = %s; want upper case", id)
+	}
 }
 
 func TestGenerateUniqueOrderID_Uniqueness(t *testing.T) {
@@ -37,3 +40,33 @@ func TestGenerateUniqueOrderID_Uniqueness(t *testing.T) {
 		ids[id] = true
 	}
 }
+
+func TestExtractOrderID(t *testing.T) {
+	cases := map[string]string{
+		"NL20261018093015AB3DE5FG":                       "NL20261018093015AB3DE5FG",
+		"MBVCB.3278.NL20261018093015AB3DE5FG.CT tu 0123": "NL20261018093015AB3DE5FG",
+		"MBVCB.3278.NL20261018093015aB3dE5fG.CT tu 0123": "NL20261018093015aB3dE5fG",
+		"thanh toan nl20261018093015ab3de5fg cam on":     "NL20261018093015ab3de5fg",
+		"NL2026101809301 too short":                      "",
+		"chuyen tien an trua":                            "",
+	}
+	for content, want := range cases {
+		if got := ExtractOrderID(content); got != want {
+			t.Errorf("ExtractOrderID(%q) = %q; want %q", content, got, want)
+		}
+	}
+}
+
+func TestOrderIDCandidates(t *testing.T) {
+	cases := map[string][]string{
+		// legacy mixed-case ID, matched as written before upper-casing
+		"NL20261018093015aB3dE5fG": {"NL20261018093015aB3dE5fG", "NL20261018093015AB3DE5FG"},
+		"NL20261018093015AB3DE5FG": {"NL20261018093015AB3DE5FG"},
+	}
+	for id, want := range cases {
+		got := OrderIDCandidates(id)
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("OrderIDCandidates(%q) = %v; want %v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"Cây Lưỡi Hổ", "cay-luoi-ho"},
+		{"Đèn ĐỎ", "den-do"},
+		{"  Trầu bà -- Nam Mỹ (size M) ", "trau-ba-nam-my-size-m"},
+		{"Chậu gốm 20cm", "chau-gom-20cm"},
+		{"T-Shirt Basic", "t-shirt-basic"},
+		{"!!!", ""},
+	}
+
+	for _, c := range cases {
+		if got := Slugify(c.name); got != c.want {
+			t.Errorf("Slugify(%q) = %q; want %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VietQR is a bank transfer encoded as an EMVCo merchant-presented QR, the
+// format Vietnamese banking apps scan to prefill a transfer.
+type VietQR struct {
+	BankBIN       string
+	AccountNumber string
+	Amount        int64
+	Content       string
+}
+
+const (
+	vietQRGUID          = "A000000727"
+	vietQRServiceToAcct = "QRIBFTTA"
+	vietQRCurrencyVND   = "704"
+	vietQRCountry       = "VN"
+)
+
+// Payload returns the EMVCo string for the transfer, ending in its CRC.
+func (q *VietQR) Payload() string {
+	beneficiary := emvField("00", q.BankBIN) + emvField("01", q.AccountNumber)
+	merchant := emvField("00", vietQRGUID) + emvField("01", beneficiary) + emvField("02", vietQRServiceToAcct)
+
+	var b strings.Builder
+	b.WriteString(emvField("00", "01"))
+	// 12 marks a dynamic QR, one made for a single payment.
+	b.WriteString(emvField("01", "12"))
+	b.WriteString(emvField("38", merchant))
+	b.WriteString(emvField("53", vietQRCurrencyVND))
+	if q.Amount > 0 {
+		b.WriteString(emvField("54", fmt.Sprintf("%d", q.Amount)))
+	}
+	b.WriteString(emvField("58", vietQRCountry))
+	if q.Content != "" {
+		b.WriteString(emvField("62", emvField("08", q.Content)))
+	}
+
+	// The CRC covers everything up to and including its own tag and length.
+	b.WriteString("6304")
+	payload := b.String()
+	return payload + fmt.Sprintf("%04X", CRC16CCITT([]byte(payload)))
+}
+
+func emvField(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// CRC16CCITT computes the CRC-16/CCITT-FALSE checksum EMVCo QR codes end with.
+func CRC16CCITT(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCRC16CCITT(t *testing.T) {
+	if got := CRC16CCITT([]byte("123456789")); got != 0x29B1 {
+		t.Errorf("CRC16CCITT(123456789) = %04X; want 29B1", got)
+	}
+}
+
+func TestVietQRPayload(t *testing.T) {
+	qr := VietQR{BankBIN: "970436", AccountNumber: "0011001234567", Amount: 150000, Content: "NL20261018101500AbCd1234"}
+
+	body := "000201010212" +
+		"38570010A00000072701270006970436011300110012345670208QRIBFTTA" +
+		"5303704" + "5406150000" + "5802VN" +
+		"62280824NL20261018101500AbCd1234" +
+		"6304"
+	want := body + fmt.Sprintf("%04X", CRC16CCITT([]byte(body)))
+
+	if got := qr.Payload(); got != want {
+		t.Errorf("Payload() = %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,26 @@
+package common
+
+import (
+	"context"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/constant"
+)
+
+// ZaloUser is the mini app customer making the request, identified by Zalo
+// user ID and, when the app shared it, the phone number.
+type ZaloUser struct {
+	ID    string
+	Phone string
+}
+
+func WithZaloUser(ctx context.Context, user *ZaloUser) context.Context {
+	return context.WithValue(ctx, constant.ZaloUserName, user)
+}
+
+func GetZaloUser(ctx context.Context) *ZaloUser {
+	if ctx == nil {
+		return nil
+	}
+	user, _ := ctx.Value(constant.ZaloUserName).(*ZaloUser)
+	return user
+}
@@ -4,6 +4,7 @@ const (
 	AppEnvDev  = "development"
 	AppEnvProd = "production"
 
-	TraceIdName = "trace_id"
-	ServiceName = "API_Service"
+	TraceIdName  = "trace_id"
+	ZaloUserName = "zalo_user"
+	ServiceName  = "API_Service"
 )
@@ -8,17 +8,20 @@ import (
 	"time"
 
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/log"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/utils"
 )
 
 const (
 	defaultTimeout = 10 * time.Second
 	zaloGraphURL   = "https://graph.zalo.me/v2.0/me/info"
+	zaloMeURL      = "https://graph.zalo.me/v2.0/me"
 )
 
 // ZaloInfoClient is the Zalo API client.
 type ZaloInfoClient struct {
 	httpClient *http.Client
 	baseURL    string
+	meURL      string
 }
 
 // NewClient creates a new Zalo client.
@@ -31,6 +34,7 @@ func NewClient(httpClient *http.Client) *ZaloInfoClient {
 	return &ZaloInfoClient{
 		httpClient: httpClient,
 		baseURL:    zaloGraphURL,
+		meURL:      zaloMeURL,
 	}
 }
 
@@ -72,3 +76,39 @@ func (c *ZaloInfoClient) GetPhoneNumber(ctx context.Context, accessToken, code,
 	log.Info(ctx, "GetPhoneNumber success", "phoneNumber", phoneNumber)
 	return &phoneNumber, nil
 }
+
+// GetUserInfo retrieves the profile of the user owning the access token.
+func (c *ZaloInfoClient) GetUserInfo(ctx context.Context, accessToken, secretKey string) (*UserInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.meURL+"?fields=id,name,picture", nil)
+	if err != nil {
+		log.Error(ctx, "GetUserInfo: failed to create request", "error", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("access_token", accessToken)
+	req.Header.Set("appsecret_proof", utils.ComputeHmac256(accessToken, secretKey))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		log.Error(ctx, "GetUserInfo: failed to send request", "error", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Error(ctx, "GetUserInfo: unexpected status code", "statusCode", resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var userInfo UserInfo
+	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
+		log.Error(ctx, "GetUserInfo: failed to decode response", "error", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if userInfo.Error != 0 || userInfo.ID == "" {
+		return nil, fmt.Errorf("zalo error %d: %s", userInfo.Error, userInfo.Message)
+	}
+
+	return &userInfo, nil
+}
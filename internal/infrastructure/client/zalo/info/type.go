@@ -11,6 +11,8 @@ type UserInfo struct {
 	} `json:"picture"`
 	Birthday string `json:"birthday"`
 	Gender   string `json:"gender"`
+	Error    int    `json:"error"`
+	Message  string `json:"message"`
 }
 
 type UserPhoneNumberData struct {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/config"
@@ -17,25 +18,53 @@ import (
 const (
 	defaultTimeout = 10 * time.Second
 	zaloPaymentURL = "https://payment-mini.zalo.me/api"
+
+	// sandboxSuffix marks the test variant of a payment method, e.g.
+	// BANK_SANDBOX.
+	sandboxSuffix = "_SANDBOX"
 )
 
 // ZaloPaymentClient is the Zalo API client.
 type ZaloPaymentClient struct {
 	httpClient *http.Client
 	baseURL    string
+	sandbox    bool
 }
 
-// NewClient creates a new Zalo client.
-func NewClient(httpClient *http.Client) *ZaloPaymentClient {
-	if httpClient == nil {
-		httpClient = &http.Client{
-			Timeout: defaultTimeout,
-		}
+// NewClient creates a new Zalo client for the base URL, timeout and
+// environment set in cfg.
+func NewClient(cfg *config.Config) *ZaloPaymentClient {
+	timeout := cfg.ZaloPaymentTimeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	baseURL := strings.TrimSuffix(cfg.ZaloPaymentBaseURL, "/")
+	if baseURL == "" {
+		baseURL = zaloPaymentURL
 	}
+
 	return &ZaloPaymentClient{
-		httpClient: httpClient,
-		baseURL:    zaloPaymentURL,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		baseURL: baseURL,
+		sandbox: cfg.IsZaloPaymentSandbox(),
+	}
+}
+
+// MethodID returns the ID Zalo expects for method in the client's
+// environment: the sandbox variant when running against the sandbox.
+func (c *ZaloPaymentClient) MethodID(method string) string {
+	if !c.sandbox || strings.HasSuffix(method, sandboxSuffix) {
+		return method
 	}
+	return method + sandboxSuffix
+}
+
+// BaseMethod strips the sandbox marker from a Zalo payment method ID, so
+// BANK_SANDBOX is handled as BANK.
+func BaseMethod(method string) string {
+	return strings.TrimSuffix(method, sandboxSuffix)
 }
 
 // GetOrderStatus
@@ -89,6 +118,18 @@ func (c *ZaloPaymentClient) GetOrderStatus(ctx context.Context, config *config.C
 	return &response, nil
 }
 
+// NewUpdateOrderStatusRequest builds a signed COD/bank status update.
+// mac = HMAC-SHA256("appId={appId}&orderId={orderId}&resultCode={resultCode}&privateKey={privateKey}", privateKey)
+func NewUpdateOrderStatusRequest(appID, orderID string, resultCode int, privateKey string) *UpdateOrderStatusRequest {
+	dataForMac := fmt.Sprintf("appId=%s&orderId=%s&resultCode=%d&privateKey=%s", appID, orderID, resultCode, privateKey)
+	return &UpdateOrderStatusRequest{
+		AppID:      appID,
+		OrderID:    orderID,
+		ResultCode: resultCode,
+		Mac:        utils.ComputeHmac256(dataForMac, privateKey),
+	}
+}
+
 func (c *ZaloPaymentClient) UpdateCodOrderStatus(ctx context.Context, req *UpdateOrderStatusRequest) (*UpdateOrderStatusResponse, error) {
 	targetURL := fmt.Sprintf("%s/transaction/%s/cod-callback-payment", c.baseURL, req.AppID)
 	return c.sendUpdateOrderRequest(ctx, targetURL, req)
@@ -0,0 +1,92 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/config"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/log"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/utils"
+)
+
+func TestMain(m *testing.M) {
+	config.AppConfig = &config.Config{}
+	log.NewLogger()
+	os.Exit(m.Run())
+}
+
+func TestUpdateBankOrderStatus(t *testing.T) {
+	const (
+		appID      = "1234"
+		orderID    = "zalo-order-1"
+		privateKey = "secret"
+	)
+
+	var got UpdateOrderStatusRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s; want POST", r.Method)
+		}
+		if want := "/api/transaction/" + appID + "/bank-callback-payment"; r.URL.Path != want {
+			t.Errorf("path = %s; want %s", r.URL.Path, want)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Write([]byte(`{"error":0,"data":{"returnCode":1,"returnMessage":"ok"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.Config{ZaloPaymentBaseURL: server.URL + "/api/"})
+	req := NewUpdateOrderStatusRequest(appID, orderID, ResultCodeSuccess, privateKey)
+	res, err := client.UpdateBankOrderStatus(context.Background(), req)
+	if err != nil {
+		t.Fatalf("UpdateBankOrderStatus: %v", err)
+	}
+	if res.Error != 0 || res.Data.ReturnCode != 1 {
+		t.Errorf("response = %+v; want error 0, returnCode 1", res)
+	}
+
+	wantMac := utils.ComputeHmac256("appId=1234&orderId=zalo-order-1&resultCode=1&privateKey=secret", privateKey)
+	if got.AppID != appID || got.OrderID != orderID || got.ResultCode != ResultCodeSuccess {
+		t.Errorf("request = %+v; want appId %s, orderId %s, resultCode %d", got, appID, orderID, ResultCodeSuccess)
+	}
+	if got.Mac != wantMac {
+		t.Errorf("mac = %s; want %s", got.Mac, wantMac)
+	}
+}
+
+func TestUpdateBankOrderStatusUnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.Config{ZaloPaymentBaseURL: server.URL})
+	req := NewUpdateOrderStatusRequest("1234", "zalo-order-1", ResultCodeFailed, "secret")
+	if _, err := client.UpdateBankOrderStatus(context.Background(), req); err == nil {
+		t.Error("UpdateBankOrderStatus with a 502 response = nil error; want an error")
+	}
+}
+
+func TestMethodID(t *testing.T) {
+	sandbox := NewClient(&config.Config{ZaloPaymentEnv: "sandbox"})
+	if got := sandbox.MethodID("BANK"); got != "BANK_SANDBOX" {
+		t.Errorf("sandbox MethodID(BANK) = %s; want BANK_SANDBOX", got)
+	}
+	if got := sandbox.MethodID("BANK_SANDBOX"); got != "BANK_SANDBOX" {
+		t.Errorf("sandbox MethodID(BANK_SANDBOX) = %s; want BANK_SANDBOX", got)
+	}
+
+	production := NewClient(&config.Config{})
+	if got := production.MethodID("BANK"); got != "BANK" {
+		t.Errorf("production MethodID(BANK) = %s; want BANK", got)
+	}
+	if production.baseURL != zaloPaymentURL {
+		t.Errorf("default baseURL = %s; want %s", production.baseURL, zaloPaymentURL)
+	}
+}
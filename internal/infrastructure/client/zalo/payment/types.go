@@ -1,5 +1,12 @@
 package payment
 
+// Result codes reported to Zalo when updating a COD or bank order.
+const (
+	ResultCodeSuccess  = 1
+	ResultCodeRefunded = 0
+	ResultCodeFailed   = -1
+)
+
 // UpdateOrderStatusRequest represents the request payload for updating order status.
 type UpdateOrderStatusRequest struct {
 	AppID      string `json:"appId"`
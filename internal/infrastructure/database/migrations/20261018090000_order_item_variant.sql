@@ -0,0 +1,4 @@
+-- Modify "order_items" table
+ALTER TABLE "public"."order_items" ADD COLUMN "variant_id" bigint NULL;
+-- Create index "idx_order_items_variant_id" to table: "order_items"
+CREATE INDEX "idx_order_items_variant_id" ON "public"."order_items" ("variant_id");
@@ -0,0 +1,15 @@
+-- Create "order_events" table
+CREATE TABLE "public"."order_events" (
+  "id" bigserial NOT NULL,
+  "order_id" character varying(255) NOT NULL,
+  "from_status" character varying(50) NULL,
+  "to_status" character varying(50) NOT NULL,
+  "source" character varying(50) NOT NULL,
+  "actor" character varying(255) NULL,
+  "reference" character varying(255) NULL,
+  "note" text NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_order_events_order_id" to table: "order_events"
+CREATE INDEX "idx_order_events_order_id" ON "public"."order_events" ("order_id");
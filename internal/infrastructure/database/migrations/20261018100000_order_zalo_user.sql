@@ -0,0 +1,4 @@
+-- Modify "orders" table
+ALTER TABLE "public"."orders" ADD COLUMN "zalo_user_id" character varying(255) NULL;
+-- Create index "idx_orders_zalo_user_id" to table: "orders"
+CREATE INDEX "idx_orders_zalo_user_id" ON "public"."orders" ("zalo_user_id");
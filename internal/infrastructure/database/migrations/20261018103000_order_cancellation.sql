@@ -0,0 +1,2 @@
+-- Modify "orders" table
+ALTER TABLE "public"."orders" ADD COLUMN "payment_method" character varying(50) NULL, ADD COLUMN "cancel_reason" text NULL;
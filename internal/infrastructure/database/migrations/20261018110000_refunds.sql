@@ -0,0 +1,15 @@
+-- Create "refunds" table
+CREATE TABLE "public"."refunds" (
+  "id" bigserial NOT NULL,
+  "order_id" character varying(255) NOT NULL,
+  "amount" numeric(20,2) NOT NULL,
+  "reason" text NULL,
+  "reference" character varying(255) NULL,
+  "actor" character varying(255) NULL,
+  "zalo_reported" boolean NULL DEFAULT false,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_refunds_order_id" to table: "refunds"
+CREATE INDEX "idx_refunds_order_id" ON "public"."refunds" ("order_id");
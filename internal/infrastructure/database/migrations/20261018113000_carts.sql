@@ -0,0 +1,28 @@
+-- Create "carts" table
+CREATE TABLE "public"."carts" (
+  "id" bigserial NOT NULL,
+  "zalo_user_id" character varying(255) NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_carts_zalo_user_id" to table: "carts"
+CREATE UNIQUE INDEX "idx_carts_zalo_user_id" ON "public"."carts" ("zalo_user_id");
+-- Create "cart_items" table
+CREATE TABLE "public"."cart_items" (
+  "id" bigserial NOT NULL,
+  "cart_id" bigint NOT NULL,
+  "product_id" bigint NOT NULL,
+  "variant_id" bigint NULL,
+  "quantity" bigint NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_carts_items" FOREIGN KEY ("cart_id") REFERENCES "public"."carts" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_cart_items_cart_id" to table: "cart_items"
+CREATE INDEX "idx_cart_items_cart_id" ON "public"."cart_items" ("cart_id");
+-- Create index "idx_cart_items_product_id" to table: "cart_items"
+CREATE INDEX "idx_cart_items_product_id" ON "public"."cart_items" ("product_id");
+-- Create index "idx_cart_items_variant_id" to table: "cart_items"
+CREATE INDEX "idx_cart_items_variant_id" ON "public"."cart_items" ("variant_id");
@@ -0,0 +1,43 @@
+-- Create "vouchers" table
+CREATE TABLE "public"."vouchers" (
+  "id" bigserial NOT NULL,
+  "code" character varying(50) NOT NULL,
+  "description" text NULL,
+  "type" character varying(20) NOT NULL,
+  "value" numeric(20,2) NOT NULL,
+  "max_discount" numeric(20,2) NULL,
+  "min_order_value" numeric(20,2) NOT NULL DEFAULT 0,
+  "starts_at" timestamptz NULL,
+  "ends_at" timestamptz NULL,
+  "usage_limit" bigint NULL,
+  "per_phone_limit" bigint NULL,
+  "used_count" bigint NOT NULL DEFAULT 0,
+  "active" boolean NOT NULL DEFAULT true,
+  "product_ids" json NULL,
+  "category_ids" json NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_vouchers_code" to table: "vouchers"
+CREATE UNIQUE INDEX "idx_vouchers_code" ON "public"."vouchers" ("code");
+-- Create "voucher_usages" table
+CREATE TABLE "public"."voucher_usages" (
+  "id" bigserial NOT NULL,
+  "voucher_id" bigint NOT NULL,
+  "order_id" character varying(255) NOT NULL,
+  "phone" character varying(20) NOT NULL,
+  "discount" numeric(20,2) NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_voucher_usages_order_id" to table: "voucher_usages"
+CREATE UNIQUE INDEX "idx_voucher_usages_order_id" ON "public"."voucher_usages" ("order_id");
+-- Create index "idx_voucher_usages_phone" to table: "voucher_usages"
+CREATE INDEX "idx_voucher_usages_phone" ON "public"."voucher_usages" ("phone");
+-- Create index "idx_voucher_usages_voucher_id" to table: "voucher_usages"
+CREATE INDEX "idx_voucher_usages_voucher_id" ON "public"."voucher_usages" ("voucher_id");
+-- Modify "orders" table
+ALTER TABLE "public"."orders" ADD COLUMN "discount_amount" numeric(20,2) NOT NULL DEFAULT 0, ADD COLUMN "voucher_id" bigint NULL, ADD COLUMN "voucher_code" character varying(50) NULL;
+-- Create index "idx_orders_voucher_id" to table: "orders"
+CREATE INDEX "idx_orders_voucher_id" ON "public"."orders" ("voucher_id");
@@ -0,0 +1,29 @@
+-- Create "shipping_rules" table
+CREATE TABLE "public"."shipping_rules" (
+  "id" bigserial NOT NULL,
+  "name" character varying(255) NOT NULL,
+  "province" character varying(100) NULL,
+  "min_weight" bigint NOT NULL DEFAULT 0,
+  "max_weight" bigint NULL,
+  "base_fee" bigint NOT NULL DEFAULT 0,
+  "included_weight" bigint NOT NULL DEFAULT 0,
+  "fee_per_kg" bigint NOT NULL DEFAULT 0,
+  "free_shipping_threshold" bigint NULL,
+  "priority" bigint NOT NULL DEFAULT 0,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_shipping_rules_province" to table: "shipping_rules"
+CREATE INDEX "idx_shipping_rules_province" ON "public"."shipping_rules" ("province");
+-- Seed a free catch-all rule so checkout keeps working until real rules are configured
+INSERT INTO "public"."shipping_rules" ("name", "created_at", "updated_at") VALUES ('Default', now(), now());
+-- Modify "products" table
+ALTER TABLE "public"."products" ADD COLUMN "weight" bigint NOT NULL DEFAULT 0;
+-- Modify "product_variants" table
+ALTER TABLE "public"."product_variants" ADD COLUMN "weight" bigint NOT NULL DEFAULT 0;
+-- Modify "orders" table
+ALTER TABLE "public"."orders" ADD COLUMN "subtotal" numeric(20,2) NOT NULL DEFAULT 0, ADD COLUMN "shipping_fee" numeric(20,2) NOT NULL DEFAULT 0;
+-- Backfill the subtotal of existing orders, which had no shipping line
+UPDATE "public"."orders" SET "subtotal" = COALESCE("total_amount", 0) + "discount_amount";
@@ -0,0 +1,5 @@
+-- Modify "orders" table
+ALTER TABLE "public"."orders" ADD COLUMN "paid_amount" numeric(20,2) NOT NULL DEFAULT 0, ADD COLUMN "payment_status" character varying(20) NOT NULL DEFAULT 'unpaid';
+-- Backfill orders whose payment was already confirmed
+UPDATE "public"."orders" SET "paid_amount" = COALESCE("total_amount", 0), "payment_status" = 'paid'
+WHERE "id" IN (SELECT "order_id" FROM "public"."order_events" WHERE "from_status" = 'paying' AND "to_status" = 'pending');
@@ -0,0 +1,21 @@
+-- Create "jobs" table
+CREATE TABLE "public"."jobs" (
+  "id" bigserial NOT NULL,
+  "type" character varying(100) NOT NULL,
+  "payload" text NULL,
+  "status" character varying(20) NOT NULL DEFAULT 'pending',
+  "run_at" timestamptz NOT NULL,
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "max_attempts" bigint NOT NULL DEFAULT 10,
+  "last_error" text NULL,
+  "locked_by" character varying(100) NULL,
+  "locked_at" timestamptz NULL,
+  "completed_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_jobs_type" to table: "jobs"
+CREATE INDEX "idx_jobs_type" ON "public"."jobs" ("type");
+-- Create index "idx_jobs_status_run_at" to table: "jobs"
+CREATE INDEX "idx_jobs_status_run_at" ON "public"."jobs" ("status", "run_at");
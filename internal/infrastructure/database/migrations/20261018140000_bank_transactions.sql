@@ -0,0 +1,25 @@
+-- Create "bank_transactions" table
+CREATE TABLE "public"."bank_transactions" (
+  "id" bigserial NOT NULL,
+  "provider_id" bigint NOT NULL,
+  "gateway" character varying(100) NULL,
+  "account_number" character varying(50) NULL,
+  "reference_code" character varying(100) NULL,
+  "transfer_type" character varying(10) NULL,
+  "amount" numeric(20,2) NOT NULL,
+  "content" text NULL,
+  "transaction_date" character varying(50) NULL,
+  "payload" text NULL,
+  "status" character varying(20) NOT NULL DEFAULT 'received',
+  "note" text NULL,
+  "order_id" character varying(255) NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_bank_transactions_provider_id" to table: "bank_transactions"
+CREATE UNIQUE INDEX "idx_bank_transactions_provider_id" ON "public"."bank_transactions" ("provider_id");
+-- Create index "idx_bank_transactions_reference_code" to table: "bank_transactions"
+CREATE INDEX "idx_bank_transactions_reference_code" ON "public"."bank_transactions" ("reference_code");
+-- Create index "idx_bank_transactions_order_id" to table: "bank_transactions"
+CREATE INDEX "idx_bank_transactions_order_id" ON "public"."bank_transactions" ("order_id");
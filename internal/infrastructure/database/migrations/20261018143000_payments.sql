@@ -0,0 +1,31 @@
+-- Create "payments" table
+CREATE TABLE "public"."payments" (
+  "id" bigserial NOT NULL,
+  "order_id" character varying(255) NOT NULL,
+  "method" character varying(50) NOT NULL,
+  "amount" numeric(20,2) NOT NULL,
+  "status" character varying(20) NOT NULL DEFAULT 'pending',
+  "transaction_id" character varying(255) NULL,
+  "zalo_order_id" character varying(255) NULL,
+  "payload" text NULL,
+  "completed_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_payments_order_id" to table: "payments"
+CREATE INDEX "idx_payments_order_id" ON "public"."payments" ("order_id");
+-- Create index "idx_payments_transaction_id" to table: "payments"
+CREATE INDEX "idx_payments_transaction_id" ON "public"."payments" ("transaction_id");
+-- Create index "idx_payments_zalo_order_id" to table: "payments"
+CREATE INDEX "idx_payments_zalo_order_id" ON "public"."payments" ("zalo_order_id");
+-- Backfill one attempt per existing order from the payment fields on the order
+INSERT INTO "public"."payments" ("order_id", "method", "amount", "status", "transaction_id", "zalo_order_id", "completed_at", "created_at", "updated_at")
+SELECT "id", COALESCE("payment_method", ''),
+  CASE WHEN "paid_amount" > 0 THEN "paid_amount" ELSE COALESCE("total_amount", 0) END,
+  CASE WHEN "paid_amount" > 0 THEN 'succeeded' WHEN "status" = 'failed' THEN 'failed' ELSE 'pending' END,
+  "transaction_id", "zalo_order_id",
+  CASE WHEN "paid_amount" > 0 OR "status" = 'failed' THEN "updated_at" END,
+  "created_at", "updated_at"
+FROM "public"."orders"
+WHERE "paid_amount" > 0 OR "status" <> 'cancelled';
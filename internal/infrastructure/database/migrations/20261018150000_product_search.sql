@@ -0,0 +1,14 @@
+-- Add extensions for accent-insensitive and typo-tolerant product search
+CREATE EXTENSION IF NOT EXISTS "unaccent" WITH SCHEMA "public";
+CREATE EXTENSION IF NOT EXISTS "pg_trgm" WITH SCHEMA "public";
+-- unaccent is only stable, as its dictionary can change; indexes need an immutable function
+CREATE OR REPLACE FUNCTION "public"."immutable_unaccent"(text) RETURNS text
+  LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+  AS $$ SELECT "public"."unaccent"('"public"."unaccent"'::regdictionary, $1) $$;
+-- Create index "idx_products_search" to table: "products"
+CREATE INDEX "idx_products_search" ON "public"."products" USING gin ((
+  setweight(to_tsvector('simple', "public"."immutable_unaccent"("name")), 'A') ||
+  setweight(to_tsvector('simple', "public"."immutable_unaccent"(COALESCE("description", ''))), 'B')
+));
+-- Create index "idx_products_name_trgm" to table: "products"
+CREATE INDEX "idx_products_name_trgm" ON "public"."products" USING gin ((lower("public"."immutable_unaccent"("name"))) "public"."gin_trgm_ops");
@@ -0,0 +1,23 @@
+-- Modify "categories" table
+ALTER TABLE "public"."categories" ADD COLUMN "parent_id" bigint NULL, ADD CONSTRAINT "fk_categories_children" FOREIGN KEY ("parent_id") REFERENCES "public"."categories" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION;
+-- Create index "idx_categories_parent_id" to table: "categories"
+CREATE INDEX "idx_categories_parent_id" ON "public"."categories" ("parent_id");
+-- Create "tags" table
+CREATE TABLE "public"."tags" (
+  "id" bigserial NOT NULL,
+  "name" character varying(100) NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_tags_name" to table: "tags"
+CREATE UNIQUE INDEX "idx_tags_name" ON "public"."tags" ("name");
+-- Create "product_tags" table
+CREATE TABLE "public"."product_tags" (
+  "product_id" bigint NOT NULL,
+  "tag_id" bigint NOT NULL,
+  PRIMARY KEY ("product_id", "tag_id"),
+  CONSTRAINT "fk_product_tags_product" FOREIGN KEY ("product_id") REFERENCES "public"."products" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION,
+  CONSTRAINT "fk_product_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "public"."tags" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION
+);
+-- Create index "idx_order_items_product_id" to table: "order_items"
+CREATE INDEX "idx_order_items_product_id" ON "public"."order_items" ((("product_snapshot" ->> 'product_id')::bigint));
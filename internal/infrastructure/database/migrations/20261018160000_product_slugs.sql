@@ -0,0 +1,27 @@
+-- Modify "products" table
+ALTER TABLE "public"."products" ADD COLUMN "slug" character varying(255) NULL, ADD COLUMN "meta_title" character varying(255) NULL, ADD COLUMN "meta_description" character varying(500) NULL;
+-- Backfill slugs from names without diacritics; names that give the same slug get the product ID appended
+WITH "base" AS (
+  SELECT "id", COALESCE(NULLIF(trim(BOTH '-' FROM regexp_replace(lower("public"."immutable_unaccent"("name")), '[^a-z0-9]+', '-', 'g')), ''), 'product') AS "slug"
+  FROM "public"."products"
+), "ranked" AS (
+  SELECT "id", "slug", row_number() OVER (PARTITION BY "slug" ORDER BY "id") AS "n" FROM "base"
+)
+UPDATE "public"."products" SET "slug" = CASE WHEN "ranked"."n" = 1 THEN "ranked"."slug" ELSE "ranked"."slug" || '-' || "ranked"."id" END
+FROM "ranked" WHERE "ranked"."id" = "products"."id";
+-- Modify "products" table
+ALTER TABLE "public"."products" ALTER COLUMN "slug" SET NOT NULL;
+-- Create index "idx_products_slug" to table: "products"
+CREATE UNIQUE INDEX "idx_products_slug" ON "public"."products" ("slug");
+-- Create "product_slug_redirects" table
+CREATE TABLE "public"."product_slug_redirects" (
+  "id" bigserial NOT NULL,
+  "slug" character varying(255) NOT NULL,
+  "product_id" bigint NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_product_slug_redirects_product_id" to table: "product_slug_redirects"
+CREATE INDEX "idx_product_slug_redirects_product_id" ON "public"."product_slug_redirects" ("product_id");
+-- Create index "idx_product_slug_redirects_slug" to table: "product_slug_redirects"
+CREATE UNIQUE INDEX "idx_product_slug_redirects_slug" ON "public"."product_slug_redirects" ("slug");
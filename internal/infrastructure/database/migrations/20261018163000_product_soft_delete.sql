@@ -0,0 +1,12 @@
+-- Modify "products" table
+ALTER TABLE "public"."products" ADD COLUMN "deleted_at" timestamptz NULL;
+-- Create index "idx_products_deleted_at" to table: "products"
+CREATE INDEX "idx_products_deleted_at" ON "public"."products" ("deleted_at");
+-- Modify "product_variants" table
+ALTER TABLE "public"."product_variants" ADD COLUMN "deleted_at" timestamptz NULL;
+-- Create index "idx_product_variants_deleted_at" to table: "product_variants"
+CREATE INDEX "idx_product_variants_deleted_at" ON "public"."product_variants" ("deleted_at");
+-- Modify "product_images" table
+ALTER TABLE "public"."product_images" ADD COLUMN "deleted_at" timestamptz NULL;
+-- Create index "idx_product_images_deleted_at" to table: "product_images"
+CREATE INDEX "idx_product_images_deleted_at" ON "public"."product_images" ("deleted_at");
@@ -0,0 +1,2 @@
+-- Modify "products" table
+ALTER TABLE "public"."products" ADD COLUMN "status" character varying(20) NOT NULL DEFAULT 'published', ADD COLUMN "publish_at" timestamptz NULL, ADD COLUMN "unpublish_at" timestamptz NULL;
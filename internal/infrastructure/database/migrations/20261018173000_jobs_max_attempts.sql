@@ -0,0 +1,2 @@
+-- Modify "jobs" table
+ALTER TABLE "public"."jobs" ALTER COLUMN "max_attempts" SET DEFAULT 12;
@@ -0,0 +1,16 @@
+-- Merge duplicate "cart_items" lines into the oldest one
+UPDATE "public"."cart_items" AS "kept" SET "quantity" = "merged"."quantity"
+FROM (
+  SELECT min("id") AS "id", sum("quantity") AS "quantity"
+  FROM "public"."cart_items"
+  GROUP BY "cart_id", "product_id", COALESCE("variant_id", 0)
+  HAVING count(*) > 1
+) AS "merged"
+WHERE "kept"."id" = "merged"."id";
+DELETE FROM "public"."cart_items" AS "dup" USING "public"."cart_items" AS "kept"
+WHERE "dup"."cart_id" = "kept"."cart_id"
+  AND "dup"."product_id" = "kept"."product_id"
+  AND COALESCE("dup"."variant_id", 0) = COALESCE("kept"."variant_id", 0)
+  AND "dup"."id" > "kept"."id";
+-- Create index "idx_cart_items_line" to table: "cart_items"
+CREATE UNIQUE INDEX "idx_cart_items_line" ON "public"."cart_items" ("cart_id", "product_id", (COALESCE("variant_id", 0)));
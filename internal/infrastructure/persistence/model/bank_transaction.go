@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type BankTransactionStatus string
+
+const (
+	// BankTransactionStatusReceived is a transaction stored but not yet
+	// applied or ignored, for example because processing was interrupted.
+	BankTransactionStatusReceived BankTransactionStatus = "received"
+	BankTransactionStatusApplied  BankTransactionStatus = "applied"
+	BankTransactionStatusIgnored  BankTransactionStatus = "ignored"
+)
+
+// BankTransaction is a transfer reported by the bank webhook. ProviderID is
+// the bank's own ID for the transfer, so a redelivered webhook maps to the
+// same row.
+type BankTransaction struct {
+	ID              uint                  `gorm:"primaryKey" json:"id"`
+	ProviderID      int64                 `gorm:"not null;uniqueIndex" json:"provider_id"`
+	Gateway         string                `gorm:"type:varchar(100)" json:"gateway"`
+	AccountNumber   string                `gorm:"type:varchar(50)" json:"account_number"`
+	ReferenceCode   string                `gorm:"type:varchar(100);index" json:"reference_code"`
+	TransferType    string                `gorm:"type:varchar(10)" json:"transfer_type"`
+	Amount          decimal.Decimal       `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Content         string                `gorm:"type:text" json:"content"`
+	TransactionDate string                `gorm:"type:varchar(50)" json:"transaction_date"`
+	Payload         string                `gorm:"type:text" json:"payload"`
+	Status          BankTransactionStatus `gorm:"type:varchar(20);not null;default:'received'" json:"status"`
+	Note            string                `gorm:"type:text" json:"note,omitempty"`
+	OrderID         *string               `gorm:"type:varchar(255);index" json:"order_id,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (BankTransaction) TableName() string {
+	return "bank_transactions"
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Cart is the shopping cart of a mini app customer, kept on the server so it
+// follows the Zalo user across devices.
+type Cart struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	ZaloUserID string `gorm:"type:varchar(255);not null;uniqueIndex" json:"zalo_user_id"`
+
+	Items []CartItem `gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Cart) TableName() string {
+	return "carts"
+}
+
+// CartItem stores only what the customer picked; prices and stock are read
+// from the catalog each time the cart is shown.
+type CartItem struct {
+	ID        uint  `gorm:"primaryKey" json:"id"`
+	CartID    uint  `gorm:"index;not null" json:"cart_id"`
+	ProductID uint  `gorm:"index;not null" json:"product_id"`
+	VariantID *uint `gorm:"index" json:"variant_id,omitempty"`
+	Quantity  int   `gorm:"not null" json:"quantity"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (CartItem) TableName() string {
+	return "cart_items"
+}
@@ -9,6 +9,9 @@ type Category struct {
 	Name string `gorm:"type:varchar(255);not null" json:"name"`
 	Slug string `gorm:"type:varchar(255);not null;uniqueIndex" json:"slug"`
 
+	ParentID *uint      `gorm:"index" json:"parent_id,omitempty"`
+	Children []Category `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+
 	ImageID   *uint     `gorm:"index" json:"image_id,omitempty"`
 	Image     *Image    `gorm:"foreignKey:ImageID" json:"image,omitempty"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobStatusPending JobStatus = "pending"
+	JobStatusRunning JobStatus = "running"
+	JobStatusDone    JobStatus = "done"
+	JobStatusFailed  JobStatus = "failed"
+)
+
+// Job is a unit of background work stored in Postgres. Workers claim pending
+// jobs whose RunAt has passed; a failed run is rescheduled with backoff until
+// MaxAttempts runs have been made, after which the job stays failed.
+type Job struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Type        string     `gorm:"type:varchar(100);not null;index" json:"type"`
+	Payload     string     `gorm:"type:text" json:"payload"`
+	Status      JobStatus  `gorm:"type:varchar(20);not null;default:'pending';index:idx_jobs_status_run_at,priority:1" json:"status"`
+	RunAt       time.Time  `gorm:"not null;index:idx_jobs_status_run_at,priority:2" json:"run_at"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"not null;default:12" json:"max_attempts"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	LockedBy    string     `gorm:"type:varchar(100)" json:"locked_by,omitempty"`
+	LockedAt    *time.Time `json:"locked_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+const (
+	jobRetryBaseDelay = 30 * time.Second
+	jobRetryMaxDelay  = time.Hour
+)
+
+// RetryDelay is how long to wait before running a job again after its
+// attempts-th run failed. The delay doubles with every attempt, starting at
+// 30 seconds and capped at an hour.
+func RetryDelay(attempts int) time.Duration {
+	delay := jobRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= jobRetryMaxDelay {
+			return jobRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// CanRetry reports whether the job has runs left after its latest attempt.
+func (j *Job) CanRetry() bool {
+	return j.Attempts < j.MaxAttempts
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+
+	for _, c := range cases {
+		if got := RetryDelay(c.attempts); got != c.want {
+			t.Errorf("RetryDelay(%d) = %s; want %s", c.attempts, got, c.want)
+		}
+	}
+}
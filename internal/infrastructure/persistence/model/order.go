@@ -7,9 +7,10 @@ import (
 )
 
 type CustomerInfo struct {
-	Name    string `json:"name"`
-	Phone   string `json:"phone"`
-	Address string `json:"address"`
+	Name     string `json:"name"`
+	Phone    string `json:"phone"`
+	Address  string `json:"address"`
+	Province string `json:"province,omitempty"`
 }
 
 type OrderStatus string
@@ -25,15 +26,89 @@ const (
 	OrderStatusRefunded   OrderStatus = "refunded"
 )
 
+// orderStatusTransitions is the order lifecycle: the statuses an order may
+// move to from each status. Statuses without an entry are final.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPaying:     {OrderStatusPending, OrderStatusFailed, OrderStatusCancelled},
+	OrderStatusPending:    {OrderStatusProcessing, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusProcessing: {OrderStatusShipping, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusShipping:   {OrderStatusCompleted, OrderStatusFailed, OrderStatusRefunded},
+	OrderStatusCompleted:  {OrderStatusRefunded},
+	OrderStatusFailed:     {OrderStatusRefunded},
+	OrderStatusCancelled:  {OrderStatusRefunded},
+}
+
+// IsValid reports whether s is one of the defined order statuses.
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusFailed, OrderStatusCancelled, OrderStatusPaying, OrderStatusPending,
+		OrderStatusProcessing, OrderStatusShipping, OrderStatusCompleted, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether an order in status s may move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ReleasesStock reports whether an order in this status no longer holds the
+// variant stock reserved for it at creation.
+func (s OrderStatus) ReleasesStock() bool {
+	return s == OrderStatusFailed || s == OrderStatusCancelled
+}
+
+// PaymentStatus compares what the customer has paid with the order total.
+type PaymentStatus string
+
+const (
+	PaymentStatusUnpaid    PaymentStatus = "unpaid"
+	PaymentStatusUnderpaid PaymentStatus = "underpaid"
+	PaymentStatusPaid      PaymentStatus = "paid"
+	PaymentStatusOverpaid  PaymentStatus = "overpaid"
+)
+
+// PaymentStatusFor returns the payment status of an order of total after paid
+// has been received.
+func PaymentStatusFor(paid, total decimal.Decimal) PaymentStatus {
+	switch {
+	case !paid.IsPositive():
+		return PaymentStatusUnpaid
+	case paid.LessThan(total):
+		return PaymentStatusUnderpaid
+	case paid.Equal(total):
+		return PaymentStatusPaid
+	default:
+		return PaymentStatusOverpaid
+	}
+}
+
 type Order struct {
-	ID            string          `gorm:"primaryKey;type:varchar(255)" json:"id"`
-	CustomerInfo  *CustomerInfo   `gorm:"serializer:json;type:json" json:"customer_info,omitempty"`
-	TotalAmount   decimal.Decimal `gorm:"type:decimal(20,2)" json:"total_amount"`
-	Status        OrderStatus     `gorm:"type:varchar(50);default:'pending'" json:"status"`
-	TransactionID *string         `gorm:"type:varchar(255)" json:"transaction_id,omitempty"`
-	ZaloOrderID   *string         `gorm:"type:varchar(255)" json:"zalo_order_id,omitempty"`
+	ID             string          `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	CustomerInfo   *CustomerInfo   `gorm:"serializer:json;type:json" json:"customer_info,omitempty"`
+	Subtotal       decimal.Decimal `gorm:"type:decimal(20,2);not null;default:0" json:"subtotal"`
+	ShippingFee    decimal.Decimal `gorm:"type:decimal(20,2);not null;default:0" json:"shipping_fee"`
+	TotalAmount    decimal.Decimal `gorm:"type:decimal(20,2)" json:"total_amount"`
+	PaidAmount     decimal.Decimal `gorm:"type:decimal(20,2);not null;default:0" json:"paid_amount"`
+	PaymentStatus  PaymentStatus   `gorm:"type:varchar(20);not null;default:'unpaid'" json:"payment_status"`
+	DiscountAmount decimal.Decimal `gorm:"type:decimal(20,2);not null;default:0" json:"discount_amount"`
+	VoucherID      *uint           `gorm:"index" json:"voucher_id,omitempty"`
+	VoucherCode    *string         `gorm:"type:varchar(50)" json:"voucher_code,omitempty"`
+	Status         OrderStatus     `gorm:"type:varchar(50);default:'pending'" json:"status"`
+	PaymentMethod  string          `gorm:"type:varchar(50)" json:"payment_method,omitempty"`
+	CancelReason   *string         `gorm:"type:text" json:"cancel_reason,omitempty"`
+	TransactionID  *string         `gorm:"type:varchar(255)" json:"transaction_id,omitempty"`
+	ZaloOrderID    *string         `gorm:"type:varchar(255)" json:"zalo_order_id,omitempty"`
+	ZaloUserID     *string         `gorm:"type:varchar(255);index" json:"zalo_user_id,omitempty"`
 
 	OrderItems []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
+	Payments   []Payment   `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
 
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
@@ -45,7 +120,9 @@ func (Order) TableName() string {
 
 type ProductSnapshot struct {
 	ProductID   uint            `json:"product_id"`
+	VariantID   *uint           `json:"variant_id,omitempty"`
 	Name        string          `json:"name"`
+	VariantName string          `json:"variant_name,omitempty"`
 	Price       decimal.Decimal `json:"price"`
 	Description string          `json:"description,omitempty"`
 	ImageURL    string          `json:"image_url,omitempty"`
@@ -54,6 +131,7 @@ type ProductSnapshot struct {
 type OrderItem struct {
 	ID              uint             `gorm:"primaryKey" json:"id"`
 	OrderID         string           `gorm:"index;type:varchar(255)" json:"order_id"`
+	VariantID       *uint            `gorm:"index" json:"variant_id,omitempty"`
 	ProductSnapshot *ProductSnapshot `gorm:"serializer:json;type:json" json:"product_snapshot"`
 	Quantity        int              `json:"quantity"`
 	Price           decimal.Decimal  `gorm:"type:decimal(20,2)" json:"price"`
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+type OrderEventSource string
+
+const (
+	OrderEventSourceCheckout       OrderEventSource = "checkout"
+	OrderEventSourceAdminAPI       OrderEventSource = "admin_api"
+	OrderEventSourceCustomerAPI    OrderEventSource = "customer_api"
+	OrderEventSourceZaloCallback   OrderEventSource = "zalo_order_callback"
+	OrderEventSourceZaloNotify     OrderEventSource = "zalo_notify_callback"
+	OrderEventSourceBankWebhook    OrderEventSource = "bank_webhook"
+	OrderEventSourceReconciliation OrderEventSource = "reconciliation_job"
+	OrderEventSourceSystem         OrderEventSource = "system"
+)
+
+// OrderEvent records one status change of an order: what it moved from and
+// to, which channel caused it, who acted and the provider reference (trans ID,
+// webhook ID) that came with it.
+type OrderEvent struct {
+	ID         uint             `gorm:"primaryKey" json:"id"`
+	OrderID    string           `gorm:"index;type:varchar(255);not null" json:"order_id"`
+	FromStatus OrderStatus      `gorm:"type:varchar(50)" json:"from_status,omitempty"`
+	ToStatus   OrderStatus      `gorm:"type:varchar(50);not null" json:"to_status"`
+	Source     OrderEventSource `gorm:"type:varchar(50);not null" json:"source"`
+	Actor      string           `gorm:"type:varchar(255)" json:"actor,omitempty"`
+	Reference  string           `gorm:"type:varchar(255)" json:"reference,omitempty"`
+	Note       string           `gorm:"type:text" json:"note,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (OrderEvent) TableName() string {
+	return "order_events"
+}
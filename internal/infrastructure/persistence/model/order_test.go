@@ -0,0 +1,79 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOrderStatusCanTransitionTo(t *testing.T) {
+	cases := []struct {
+		from, to OrderStatus
+		want     bool
+	}{
+		{OrderStatusPaying, OrderStatusPending, true},
+		{OrderStatusPaying, OrderStatusFailed, true},
+		{OrderStatusPending, OrderStatusProcessing, true},
+		{OrderStatusPending, OrderStatusRefunded, true},
+		{OrderStatusProcessing, OrderStatusShipping, true},
+		{OrderStatusShipping, OrderStatusCompleted, true},
+		{OrderStatusCompleted, OrderStatusRefunded, true},
+		{OrderStatusFailed, OrderStatusRefunded, true},
+		{OrderStatusPaying, OrderStatusCompleted, false},
+		{OrderStatusShipping, OrderStatusCancelled, false},
+		{OrderStatusCompleted, OrderStatusPending, false},
+		{OrderStatusFailed, OrderStatusPending, false},
+		{OrderStatusRefunded, OrderStatusCompleted, false},
+		{OrderStatus("success"), OrderStatusPending, false},
+	}
+
+	for _, c := range cases {
+		if got := c.from.CanTransitionTo(c.to); got != c.want {
+			t.Errorf("%s -> %s = %v; want %v", c.from, c.to, got, c.want)
+		}
+	}
+}
+
+func TestOrderStatusIsValid(t *testing.T) {
+	if !OrderStatusShipping.IsValid() {
+		t.Errorf("IsValid(%s) = false; want true", OrderStatusShipping)
+	}
+	if OrderStatus("success").IsValid() {
+		t.Errorf("IsValid(success) = true; want false")
+	}
+}
+
+func TestPaymentStatusFor(t *testing.T) {
+	total := decimal.NewFromInt(250000)
+	cases := []struct {
+		paid int64
+		want PaymentStatus
+	}{
+		{0, PaymentStatusUnpaid},
+		{100000, PaymentStatusUnderpaid},
+		{250000, PaymentStatusPaid},
+		{300000, PaymentStatusOverpaid},
+	}
+
+	for _, c := range cases {
+		if got := PaymentStatusFor(decimal.NewFromInt(c.paid), total); got != c.want {
+			t.Errorf("PaymentStatusFor(%d, %s) = %s; want %s", c.paid, total, got, c.want)
+		}
+	}
+}
+
+func TestPaidAmount(t *testing.T) {
+	payments := []Payment{
+		{Method: "BANK", Amount: decimal.NewFromInt(100000), Status: PaymentAttemptSucceeded},
+		{Method: "BANK", Amount: decimal.NewFromInt(50000), Status: PaymentAttemptFailed},
+		{Method: "COD", Amount: decimal.NewFromInt(150000), Status: PaymentAttemptPending},
+		{Method: "BANK", Amount: decimal.NewFromInt(150000), Status: PaymentAttemptSucceeded},
+	}
+
+	if got := PaidAmount(payments); !got.Equal(decimal.NewFromInt(250000)) {
+		t.Errorf("PaidAmount = %s; want 250000", got)
+	}
+	if got := PaidAmount(nil); !got.IsZero() {
+		t.Errorf("PaidAmount(nil) = %s; want 0", got)
+	}
+}
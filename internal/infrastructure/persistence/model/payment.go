@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type PaymentAttemptStatus string
+
+const (
+	PaymentAttemptPending   PaymentAttemptStatus = "pending"
+	PaymentAttemptSucceeded PaymentAttemptStatus = "succeeded"
+	PaymentAttemptFailed    PaymentAttemptStatus = "failed"
+	// PaymentAttemptSuperseded closes an attempt whose order was paid through
+	// another one before it completed; its money is not counted.
+	PaymentAttemptSuperseded PaymentAttemptStatus = "superseded"
+)
+
+// Payment is one attempt to pay for an order. A customer who retries or
+// switches method adds an attempt instead of overwriting the previous one;
+// the order's paid amount is the sum of its succeeded attempts.
+type Payment struct {
+	ID            uint                 `gorm:"primaryKey" json:"id"`
+	OrderID       string               `gorm:"index;type:varchar(255);not null" json:"order_id"`
+	Method        string               `gorm:"type:varchar(50);not null" json:"method"`
+	Amount        decimal.Decimal      `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Status        PaymentAttemptStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	TransactionID *string              `gorm:"type:varchar(255);index" json:"transaction_id,omitempty"`
+	ZaloOrderID   *string              `gorm:"type:varchar(255);index" json:"zalo_order_id,omitempty"`
+	Payload       string               `gorm:"type:text" json:"-"` // raw provider callback, kept out of API responses
+	CompletedAt   *time.Time           `json:"completed_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Payment) TableName() string {
+	return "payments"
+}
+
+// PaidAmount is the money received through the succeeded payments.
+func PaidAmount(payments []Payment) decimal.Decimal {
+	paid := decimal.Zero
+	for _, payment := range payments {
+		if payment.Status == PaymentAttemptSucceeded {
+			paid = paid.Add(payment.Amount)
+		}
+	}
+	return paid
+}
@@ -1,34 +1,121 @@
 package model
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductStatus decides whether a product shows on the storefront.
+type ProductStatus string
+
+const (
+	ProductStatusDraft     ProductStatus = "draft"
+	ProductStatusScheduled ProductStatus = "scheduled"
+	ProductStatusPublished ProductStatus = "published"
+)
 
 type Product struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	CategoryID  uint      `gorm:"index" json:"category_id"`
 	Category    *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
 	Name        string    `gorm:"type:varchar(255);unique" json:"name"`
+	Slug        string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"slug"`
 	Description *string   `gorm:"type:text" json:"description,omitempty"`
 	Price       int64     `gorm:"type:bigint" json:"price,omitempty"`
+	Weight      int64     `gorm:"type:bigint;not null;default:0" json:"weight,omitempty"` // grams
+
+	MetaTitle       *string `gorm:"type:varchar(255)" json:"meta_title,omitempty"`
+	MetaDescription *string `gorm:"type:varchar(500)" json:"meta_description,omitempty"`
+
+	// A scheduled product shows from PublishAt, and any product stops showing
+	// at UnpublishAt. The publication job then updates Status to match.
+	Status      ProductStatus `gorm:"type:varchar(20);not null;default:'published'" json:"status"`
+	PublishAt   *time.Time    `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time    `json:"unpublish_at,omitempty"`
 
 	Variants      []ProductVariant `gorm:"foreignKey:ProductID" json:"variants,omitempty"`
 	ProductImages []ProductImage   `gorm:"foreignKey:ProductID" json:"product_images,omitempty"`
+	Tags          []Tag            `gorm:"many2many:product_tags" json:"tags,omitempty"`
 	CreatedAt     time.Time        `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt     time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+	// DeletedAt is set while the product is archived. Its variants and
+	// images archived along with it share the timestamp.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 func (Product) TableName() string {
 	return "products"
 }
 
-type ProductVariant struct {
+// IsVisibleAt reports whether the product shows on the storefront at t.
+func (p *Product) IsVisibleAt(t time.Time) bool {
+	switch p.Status {
+	case ProductStatusPublished:
+	case ProductStatusScheduled:
+		if p.PublishAt == nil || t.Before(*p.PublishAt) {
+			return false
+		}
+	default:
+		return false
+	}
+	return p.UnpublishAt == nil || t.Before(*p.UnpublishAt)
+}
+
+// CheckPublication validates the status and schedule of a product saved at
+// now. Only a scheduled product may have a PublishAt still to come; a past
+// one is kept from when the product went live.
+func (p *Product) CheckPublication(now time.Time) error {
+	switch p.Status {
+	case ProductStatusDraft, ProductStatusPublished:
+		if p.PublishAt != nil && p.PublishAt.After(now) {
+			return errors.New("a product published later must be scheduled")
+		}
+	case ProductStatusScheduled:
+		if p.PublishAt == nil {
+			return errors.New("publish_at is required to schedule a product")
+		}
+	default:
+		return fmt.Errorf("unknown product status %q", p.Status)
+	}
+
+	if p.UnpublishAt != nil {
+		if !p.UnpublishAt.After(now) {
+			return errors.New("unpublish_at must be in the future")
+		}
+		if p.PublishAt != nil && !p.UnpublishAt.After(*p.PublishAt) {
+			return errors.New("unpublish_at must be after publish_at")
+		}
+	}
+	return nil
+}
+
+// ProductSlugRedirect is a slug a product was known by before it changed, so
+// links shared with the old slug still find the product.
+type ProductSlugRedirect struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	ProductID uint      `gorm:"index" json:"product_id"`
-	Name      string    `gorm:"type:varchar(255)" json:"name" `
-	Stock     int64     `gorm:"type:bigint" json:"stock,omitempty"`
-	Price     int64     `gorm:"type:bigint" json:"price,omitempty"`
-	Order     int       `gorm:"default:0" json:"order,omitempty"`
+	Slug      string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"slug"`
+	ProductID uint      `gorm:"not null;index" json:"product_id"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (ProductSlugRedirect) TableName() string {
+	return "product_slug_redirects"
+}
+
+type ProductVariant struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	ProductID uint           `gorm:"index" json:"product_id"`
+	Name      string         `gorm:"type:varchar(255)" json:"name" `
+	Stock     int64          `gorm:"type:bigint" json:"stock,omitempty"`
+	Price     int64          `gorm:"type:bigint" json:"price,omitempty"`
+	Weight    int64          `gorm:"type:bigint;not null;default:0" json:"weight,omitempty"` // grams, 0 uses the product's
+	Order     int            `gorm:"default:0" json:"order,omitempty"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 func (ProductVariant) TableName() string {
@@ -36,14 +123,15 @@ func (ProductVariant) TableName() string {
 }
 
 type ProductImage struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	ProductID uint      `gorm:"index;not null" json:"product_id"`
-	ImageID   uint      `gorm:"index;not null" json:"image_id"`
-	Image     *Image    `gorm:"foreignKey:ImageID" json:"image,omitempty"`
-	Order     int       `gorm:"default:0" json:"order"`
-	IsMain    bool      `gorm:"default:false" json:"is_main"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	ProductID uint           `gorm:"index;not null" json:"product_id"`
+	ImageID   uint           `gorm:"index;not null" json:"image_id"`
+	Image     *Image         `gorm:"foreignKey:ImageID" json:"image,omitempty"`
+	Order     int            `gorm:"default:0" json:"order"`
+	IsMain    bool           `gorm:"default:false" json:"is_main"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 func (ProductImage) TableName() string {
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProductIsVisibleAt(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	cases := []struct {
+		name    string
+		product Product
+		want    bool
+	}{
+		{"published", Product{Status: ProductStatusPublished}, true},
+		{"draft", Product{Status: ProductStatusDraft}, false},
+		{"scheduled later", Product{Status: ProductStatusScheduled, PublishAt: &after}, false},
+		{"scheduled and due", Product{Status: ProductStatusScheduled, PublishAt: &now}, true},
+		{"scheduled without time", Product{Status: ProductStatusScheduled}, false},
+		{"unpublishing later", Product{Status: ProductStatusPublished, UnpublishAt: &after}, true},
+		{"unpublished", Product{Status: ProductStatusPublished, UnpublishAt: &now}, false},
+		{"scheduled window passed", Product{Status: ProductStatusScheduled, PublishAt: &before, UnpublishAt: &before}, false},
+	}
+
+	for _, c := range cases {
+		if got := c.product.IsVisibleAt(now); got != c.want {
+			t.Errorf("%s: IsVisibleAt = %v; want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestProductCheckPublication(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	before, after, later := now.Add(-time.Hour), now.Add(time.Hour), now.Add(2*time.Hour)
+
+	cases := []struct {
+		name    string
+		product Product
+		wantErr bool
+	}{
+		{"draft", Product{Status: ProductStatusDraft}, false},
+		{"published", Product{Status: ProductStatusPublished, PublishAt: &before}, false},
+		{"scheduled", Product{Status: ProductStatusScheduled, PublishAt: &after, UnpublishAt: &later}, false},
+		{"scheduled without time", Product{Status: ProductStatusScheduled}, true},
+		{"published in the future", Product{Status: ProductStatusPublished, PublishAt: &after}, true},
+		{"unpublish in the past", Product{Status: ProductStatusPublished, UnpublishAt: &before}, true},
+		{"unpublish before publish", Product{Status: ProductStatusScheduled, PublishAt: &later, UnpublishAt: &after}, true},
+		{"unknown status", Product{Status: "hidden"}, true},
+	}
+
+	for _, c := range cases {
+		if err := c.product.CheckPublication(now); (err != nil) != c.wantErr {
+			t.Errorf("%s: CheckPublication = %v; want error %v", c.name, err, c.wantErr)
+		}
+	}
+}
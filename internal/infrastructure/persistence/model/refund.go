@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Refund is money returned to the customer for an order. An order is only
+// moved to refunded once its refunds add up to the full total.
+type Refund struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	OrderID      string          `gorm:"index;type:varchar(255);not null" json:"order_id"`
+	Amount       decimal.Decimal `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Reason       string          `gorm:"type:text" json:"reason"`
+	Reference    string          `gorm:"type:varchar(255)" json:"reference,omitempty"`
+	Actor        string          `gorm:"type:varchar(255)" json:"actor,omitempty"`
+	ZaloReported bool            `gorm:"default:false" json:"zalo_reported"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Refund) TableName() string {
+	return "refunds"
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// ShippingRule prices delivery for orders to a province within a weight
+// band. The fee is BaseFee for the first IncludedWeight grams plus FeePerKg
+// for every started kilogram above it; orders worth at least
+// FreeShippingThreshold ship for free. A rule without a province applies to
+// every province that has no rule of its own.
+type ShippingRule struct {
+	ID                    uint   `gorm:"primaryKey" json:"id"`
+	Name                  string `gorm:"type:varchar(255);not null" json:"name"`
+	Province              string `gorm:"type:varchar(100);index" json:"province,omitempty"`
+	MinWeight             int64  `gorm:"type:bigint;not null;default:0" json:"min_weight"`
+	MaxWeight             *int64 `gorm:"type:bigint" json:"max_weight,omitempty"`
+	BaseFee               int64  `gorm:"type:bigint;not null;default:0" json:"base_fee"`
+	IncludedWeight        int64  `gorm:"type:bigint;not null;default:0" json:"included_weight"`
+	FeePerKg              int64  `gorm:"type:bigint;not null;default:0" json:"fee_per_kg"`
+	FreeShippingThreshold *int64 `gorm:"type:bigint" json:"free_shipping_threshold,omitempty"`
+	Priority              int    `gorm:"not null;default:0" json:"priority"`
+	Active                bool   `gorm:"not null;default:true" json:"active"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (ShippingRule) TableName() string {
+	return "shipping_rules"
+}
+
+// Matches reports whether the rule is active and covers a parcel of weight
+// grams sent to province. Provinces are compared ignoring case and
+// surrounding spaces.
+func (r *ShippingRule) Matches(province string, weight int64) bool {
+	if !r.Active {
+		return false
+	}
+	if r.Province != "" && !strings.EqualFold(strings.TrimSpace(r.Province), strings.TrimSpace(province)) {
+		return false
+	}
+	if weight < r.MinWeight {
+		return false
+	}
+	return r.MaxWeight == nil || weight < *r.MaxWeight
+}
+
+// FeeFor returns the fee for a parcel of weight grams holding goods worth
+// orderValue.
+func (r *ShippingRule) FeeFor(weight, orderValue int64) int64 {
+	if r.FreeShippingThreshold != nil && orderValue >= *r.FreeShippingThreshold {
+		return 0
+	}
+
+	fee := r.BaseFee
+	if extra := weight - r.IncludedWeight; extra > 0 && r.FeePerKg > 0 {
+		kilograms := (extra + 999) / 1000
+		fee += kilograms * r.FeePerKg
+	}
+	return fee
+}
+
+// SelectShippingRule picks the rule for a parcel: rules for the province win
+// over rules for every province, then the highest priority, then the oldest
+// rule. It returns nil when no rule matches.
+func SelectShippingRule(rules []ShippingRule, province string, weight int64) *ShippingRule {
+	var selected *ShippingRule
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Matches(province, weight) {
+			continue
+		}
+		if selected == nil || shippingRuleBefore(rule, selected) {
+			selected = rule
+		}
+	}
+	return selected
+}
+
+func shippingRuleBefore(a, b *ShippingRule) bool {
+	if (a.Province != "") != (b.Province != "") {
+		return a.Province != ""
+	}
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,55 @@
+package model
+
+import "testing"
+
+func TestShippingRuleFeeFor(t *testing.T) {
+	threshold := int64(500000)
+	rule := ShippingRule{BaseFee: 30000, IncludedWeight: 2000, FeePerKg: 5000, FreeShippingThreshold: &threshold}
+
+	cases := []struct {
+		weight, value, want int64
+	}{
+		{1500, 100000, 30000},
+		{2000, 100000, 30000},
+		{2001, 100000, 35000},
+		{4500, 100000, 45000},
+		{4500, 500000, 0},
+	}
+
+	for _, c := range cases {
+		if got := rule.FeeFor(c.weight, c.value); got != c.want {
+			t.Errorf("FeeFor(%d, %d) = %d; want %d", c.weight, c.value, got, c.want)
+		}
+	}
+}
+
+func TestSelectShippingRule(t *testing.T) {
+	heavy := int64(10000)
+	rules := []ShippingRule{
+		{ID: 1, Name: "default", Active: true},
+		{ID: 2, Name: "hanoi", Province: "Hà Nội", MaxWeight: &heavy, Active: true},
+		{ID: 3, Name: "default priority", Priority: 1, Active: true},
+		{ID: 4, Name: "hanoi disabled", Province: "Hà Nội", Priority: 5, Active: false},
+	}
+
+	cases := []struct {
+		province string
+		weight   int64
+		want     uint
+	}{
+		{" hà nội ", 3000, 2},
+		{"Hà Nội", 12000, 3},
+		{"Đà Nẵng", 3000, 3},
+	}
+
+	for _, c := range cases {
+		got := SelectShippingRule(rules, c.province, c.weight)
+		if got == nil || got.ID != c.want {
+			t.Errorf("SelectShippingRule(%q, %d) = %v; want rule %d", c.province, c.weight, got, c.want)
+		}
+	}
+
+	if got := SelectShippingRule(rules[1:2], "Huế", 1000); got != nil {
+		t.Errorf("SelectShippingRule(Huế) = rule %d; want nil", got.ID)
+	}
+}
@@ -0,0 +1,14 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTagNames(t *testing.T) {
+	got := NormalizeTagNames([]string{" Pet-safe", "low-light", "", "pet-safe ", "  ", "LOW-LIGHT", "indoor"})
+	want := []string{"pet-safe", "low-light", "indoor"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeTagNames = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type VoucherType string
+
+const (
+	VoucherTypePercent VoucherType = "percent"
+	VoucherTypeFixed   VoucherType = "fixed"
+)
+
+// Voucher is a discount code applied at checkout. Value is a percentage for
+// percent vouchers and an amount in VND for fixed ones. A voucher scoped to
+// products or categories only discounts the matching items; without a scope it
+// discounts the whole order.
+type Voucher struct {
+	ID            uint             `gorm:"primaryKey" json:"id"`
+	Code          string           `gorm:"type:varchar(50);not null;uniqueIndex" json:"code"`
+	Description   string           `gorm:"type:text" json:"description,omitempty"`
+	Type          VoucherType      `gorm:"type:varchar(20);not null" json:"type"`
+	Value         decimal.Decimal  `gorm:"type:decimal(20,2);not null" json:"value"`
+	MaxDiscount   *decimal.Decimal `gorm:"type:decimal(20,2)" json:"max_discount,omitempty"`
+	MinOrderValue decimal.Decimal  `gorm:"type:decimal(20,2);not null;default:0" json:"min_order_value"`
+	StartsAt      *time.Time       `json:"starts_at,omitempty"`
+	EndsAt        *time.Time       `json:"ends_at,omitempty"`
+	UsageLimit    *int             `json:"usage_limit,omitempty"`
+	PerPhoneLimit *int             `json:"per_phone_limit,omitempty"`
+	UsedCount     int              `gorm:"not null;default:0" json:"used_count"`
+	Active        bool             `gorm:"not null;default:true" json:"active"`
+	ProductIDs    []uint           `gorm:"serializer:json;type:json" json:"product_ids,omitempty"`
+	CategoryIDs   []uint           `gorm:"serializer:json;type:json" json:"category_ids,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Voucher) TableName() string {
+	return "vouchers"
+}
+
+// IsActiveAt reports whether the voucher is enabled and inside its validity
+// window at t.
+func (v *Voucher) IsActiveAt(t time.Time) bool {
+	if !v.Active {
+		return false
+	}
+	if v.StartsAt != nil && t.Before(*v.StartsAt) {
+		return false
+	}
+	if v.EndsAt != nil && !t.Before(*v.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Covers reports whether the voucher discounts a product in the given
+// category.
+func (v *Voucher) Covers(productID, categoryID uint) bool {
+	if len(v.ProductIDs) == 0 && len(v.CategoryIDs) == 0 {
+		return true
+	}
+	for _, id := range v.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	for _, id := range v.CategoryIDs {
+		if id == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscountFor returns the discount on an eligible amount, rounded down to
+// whole VND, capped by MaxDiscount and never more than the amount itself.
+func (v *Voucher) DiscountFor(eligible decimal.Decimal) decimal.Decimal {
+	if !eligible.IsPositive() {
+		return decimal.Zero
+	}
+
+	var discount decimal.Decimal
+	switch v.Type {
+	case VoucherTypePercent:
+		discount = eligible.Mul(v.Value).Div(decimal.NewFromInt(100)).Floor()
+	case VoucherTypeFixed:
+		discount = v.Value
+	default:
+		return decimal.Zero
+	}
+
+	if v.MaxDiscount != nil && discount.GreaterThan(*v.MaxDiscount) {
+		discount = *v.MaxDiscount
+	}
+	if discount.GreaterThan(eligible) {
+		discount = eligible
+	}
+	return discount
+}
+
+// VoucherUsage records one order placed with a voucher. Phone is stored in
+// local form so per-phone limits match however the number was typed.
+type VoucherUsage struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	VoucherID uint            `gorm:"index;not null" json:"voucher_id"`
+	OrderID   string          `gorm:"type:varchar(255);not null;uniqueIndex" json:"order_id"`
+	Phone     string          `gorm:"type:varchar(20);not null;index" json:"phone"`
+	Discount  decimal.Decimal `gorm:"type:decimal(20,2);not null" json:"discount"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (VoucherUsage) TableName() string {
+	return "voucher_usages"
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestVoucherDiscountFor(t *testing.T) {
+	maxDiscount := decimal.NewFromInt(30000)
+	cases := []struct {
+		name     string
+		voucher  Voucher
+		eligible int64
+		want     int64
+	}{
+		{"percent", Voucher{Type: VoucherTypePercent, Value: decimal.NewFromInt(10)}, 155555, 15555},
+		{"percent capped", Voucher{Type: VoucherTypePercent, Value: decimal.NewFromInt(50), MaxDiscount: &maxDiscount}, 100000, 30000},
+		{"fixed", Voucher{Type: VoucherTypeFixed, Value: decimal.NewFromInt(20000)}, 100000, 20000},
+		{"fixed above amount", Voucher{Type: VoucherTypeFixed, Value: decimal.NewFromInt(20000)}, 15000, 15000},
+		{"nothing eligible", Voucher{Type: VoucherTypeFixed, Value: decimal.NewFromInt(20000)}, 0, 0},
+	}
+
+	for _, c := range cases {
+		got := c.voucher.DiscountFor(decimal.NewFromInt(c.eligible))
+		if !got.Equal(decimal.NewFromInt(c.want)) {
+			t.Errorf("%s: DiscountFor(%d) = %s; want %d", c.name, c.eligible, got, c.want)
+		}
+	}
+}
+
+func TestVoucherIsActiveAt(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	cases := []struct {
+		name    string
+		voucher Voucher
+		want    bool
+	}{
+		{"open ended", Voucher{Active: true}, true},
+		{"inside window", Voucher{Active: true, StartsAt: &before, EndsAt: &after}, true},
+		{"not started", Voucher{Active: true, StartsAt: &after}, false},
+		{"ended", Voucher{Active: true, EndsAt: &now}, false},
+		{"disabled", Voucher{Active: false}, false},
+	}
+
+	for _, c := range cases {
+		if got := c.voucher.IsActiveAt(now); got != c.want {
+			t.Errorf("%s: IsActiveAt = %v; want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestVoucherCovers(t *testing.T) {
+	unscoped := Voucher{}
+	if !unscoped.Covers(1, 1) {
+		t.Errorf("unscoped voucher should cover every product")
+	}
+
+	scoped := Voucher{ProductIDs: []uint{7}, CategoryIDs: []uint{3}}
+	if !scoped.Covers(7, 1) || !scoped.Covers(2, 3) {
+		t.Errorf("scoped voucher should cover its products and categories")
+	}
+	if scoped.Covers(2, 1) {
+		t.Errorf("scoped voucher should not cover other products")
+	}
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"gorm.io/gorm/clause"
+)
+
+type BankTransactionRepository struct {
+	*baseRepository
+}
+
+func NewBankTransactionRepository(base *baseRepository) *BankTransactionRepository {
+	return &BankTransactionRepository{baseRepository: base}
+}
+
+// RecordBankTransaction stores txn unless a transaction with the same provider
+// ID exists, in which case txn is filled with the stored row. The returned
+// flag is true when that stored row was already processed, meaning the
+// delivery is a duplicate with nothing left to do.
+func (r *BankTransactionRepository) RecordBankTransaction(ctx context.Context, txn *model.BankTransaction) (bool, *common.Error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "provider_id"}}, DoNothing: true}).
+		Create(txn)
+	if result.Error != nil {
+		return false, r.returnError(ctx, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return false, nil
+	}
+
+	if err := r.db.WithContext(ctx).
+		Where("provider_id = ?", txn.ProviderID).
+		Take(txn).Error; err != nil {
+		return false, r.returnError(ctx, err)
+	}
+	return txn.Status != model.BankTransactionStatusReceived, nil
+}
+
+// MarkBankTransactionIgnored records why a transaction was not applied to any
+// order.
+func (r *BankTransactionRepository) MarkBankTransactionIgnored(ctx context.Context, id uint, note string) *common.Error {
+	if err := r.db.WithContext(ctx).
+		Model(&model.BankTransaction{}).
+		Where("id = ? AND status = ?", id, model.BankTransactionStatusReceived).
+		Updates(map[string]interface{}{
+			"status": model.BankTransactionStatusIgnored,
+			"note":   note,
+		}).Error; err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
+
+// ListBankTransactionsBetween returns the transactions received in [from, to).
+func (r *BankTransactionRepository) ListBankTransactionsBetween(ctx context.Context, from, to time.Time) ([]*model.BankTransaction, *common.Error) {
+	var txns []*model.BankTransaction
+	if err := r.db.WithContext(ctx).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at ASC, id ASC").
+		Find(&txns).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	return txns, nil
+}
+
+// ListBankTransactionsForOrders returns the transactions applied to any of
+// the orders, whenever they were received.
+func (r *BankTransactionRepository) ListBankTransactionsForOrders(ctx context.Context, orderIDs []string) ([]*model.BankTransaction, *common.Error) {
+	var txns []*model.BankTransaction
+	if len(orderIDs) == 0 {
+		return txns, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Where("order_id IN ?", orderIDs).
+		Order("created_at ASC, id ASC").
+		Find(&txns).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	return txns, nil
+}
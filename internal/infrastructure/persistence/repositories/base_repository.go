@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"errors"
 
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
 	"gorm.io/gorm"
@@ -21,6 +22,16 @@ func (b *baseRepository) returnError(ctx context.Context, err error) *common.Err
 	return common.ErrSystemError(ctx, err.Error()).SetSource(common.CurrentService)
 }
 
+// returnTxError keeps domain errors raised inside a transaction as they are and
+// wraps anything else as a system error.
+func (b *baseRepository) returnTxError(ctx context.Context, err error) *common.Error {
+	var domainErr *common.Error
+	if errors.As(err, &domainErr) {
+		return domainErr
+	}
+	return b.returnError(ctx, err)
+}
+
 func (b *baseRepository) ApplyFilter(db *gorm.DB, filters map[string]interface{}) *gorm.DB {
 	for key, value := range filters {
 		db = db.Where(key, value)
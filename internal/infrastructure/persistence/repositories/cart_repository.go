@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CartRepository struct {
+	*baseRepository
+}
+
+func NewCartRepository(base *baseRepository) *CartRepository {
+	return &CartRepository{baseRepository: base}
+}
+
+// GetOrCreateCart returns the cart of the Zalo user with its items, creating
+// an empty one on first use.
+func (r *CartRepository) GetOrCreateCart(ctx context.Context, zaloUserID string) (*model.Cart, *common.Error) {
+	cart := &model.Cart{ZaloUserID: zaloUserID}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "zalo_user_id"}}, DoNothing: true}).
+		Create(cart).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+
+	if err := r.db.WithContext(ctx).
+		Where("zalo_user_id = ?", zaloUserID).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Take(cart).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+
+	return cart, nil
+}
+
+// AddItem puts the product variant in the cart, adding to the quantity when
+// it is already there. The line is upserted against the unique index on the
+// cart, product and variant, so concurrent adds never make two lines.
+func (r *CartRepository) AddItem(ctx context.Context, cartID uint, productID uint, variantID *uint, quantity int) (*model.CartItem, *common.Error) {
+	item := &model.CartItem{
+		CartID:    cartID,
+		ProductID: productID,
+		VariantID: variantID,
+		Quantity:  quantity,
+	}
+	if err := r.db.WithContext(ctx).
+		Clauses(
+			clause.OnConflict{
+				Columns: []clause.Column{
+					{Name: "cart_id"},
+					{Name: "product_id"},
+					{Name: "(COALESCE(variant_id, 0))", Raw: true},
+				},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"quantity":   gorm.Expr("cart_items.quantity + EXCLUDED.quantity"),
+					"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+				}),
+			},
+			clause.Returning{},
+		).
+		Create(item).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	return item, nil
+}
+
+func (r *CartRepository) UpdateItemQuantity(ctx context.Context, cartID uint, itemID uint, quantity int) *common.Error {
+	res := r.db.WithContext(ctx).
+		Model(&model.CartItem{}).
+		Where("id = ? AND cart_id = ?", itemID, cartID).
+		Update("quantity", quantity)
+	if res.Error != nil {
+		return r.returnError(ctx, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return common.ErrNotFound(ctx, "Cart item", "not found")
+	}
+	return nil
+}
+
+func (r *CartRepository) RemoveItem(ctx context.Context, cartID uint, itemID uint) *common.Error {
+	res := r.db.WithContext(ctx).
+		Where("id = ? AND cart_id = ?", itemID, cartID).
+		Delete(&model.CartItem{})
+	if res.Error != nil {
+		return r.returnError(ctx, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return common.ErrNotFound(ctx, "Cart item", "not found")
+	}
+	return nil
+}
+
+func (r *CartRepository) ClearCart(ctx context.Context, cartID uint) *common.Error {
+	if err := r.db.WithContext(ctx).
+		Where("cart_id = ?", cartID).
+		Delete(&model.CartItem{}).Error; err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
@@ -77,7 +77,8 @@ func (c *CategoryRepository) DeleteCategory(ctx context.Context, id uint) *commo
 	// Implementation for deleting a category
 
 	productNumber := int64(0)
-	err := c.db.WithContext(ctx).Model(&model.Product{}).Where("category_id = ?", id).Count(&productNumber).Error
+	// Archived products still reference the category and may be restored
+	err := c.db.WithContext(ctx).Unscoped().Model(&model.Product{}).Where("category_id = ?", id).Count(&productNumber).Error
 
 	if err != nil {
 		return c.returnError(ctx, err)
@@ -87,5 +88,25 @@ func (c *CategoryRepository) DeleteCategory(ctx context.Context, id uint) *commo
 		return c.returnError(ctx, common.ErrConflict(ctx, "Category", "Cannot delete category with associated products"))
 	}
 
+	childNumber := int64(0)
+	if err := c.db.WithContext(ctx).Model(&model.Category{}).Where("parent_id = ?", id).Count(&childNumber).Error; err != nil {
+		return c.returnError(ctx, err)
+	}
+	if childNumber > 0 {
+		return common.ErrConflict(ctx, "Category", "Cannot delete category with subcategories")
+	}
+
 	return c.returnError(ctx, c.db.WithContext(ctx).Delete(&model.Category{}, id).Error)
 }
+
+// GetDescendantIDs returns the IDs of every category below id in the tree.
+func (c *CategoryRepository) GetDescendantIDs(ctx context.Context, id uint) ([]uint, *common.Error) {
+	var ids []uint
+	if err := c.db.WithContext(ctx).Raw(`WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE parent_id = ?
+		UNION SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+	) SELECT id FROM subtree`, id).Scan(&ids).Error; err != nil {
+		return nil, c.returnError(ctx, err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository struct {
+	*baseRepository
+}
+
+func NewJobRepository(base *baseRepository) *JobRepository {
+	return &JobRepository{baseRepository: base}
+}
+
+func (r *JobRepository) CreateJob(ctx context.Context, job *model.Job) *common.Error {
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
+
+// ClaimDueJobs marks up to limit due pending jobs as running for worker and
+// returns them. Rows other workers are claiming are skipped rather than
+// waited on, so workers never run the same job twice.
+func (r *JobRepository) ClaimDueJobs(ctx context.Context, worker string, limit int) ([]*model.Job, *common.Error) {
+	var jobs []*model.Job
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", model.JobStatusPending, time.Now()).
+			Order("run_at ASC, id ASC").
+			Limit(limit).
+			Find(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(jobs))
+		now := time.Now()
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+			job.Status = model.JobStatusRunning
+			job.Attempts++
+			job.LockedBy = worker
+			job.LockedAt = &now
+		}
+		return tx.Model(&model.Job{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":    model.JobStatusRunning,
+				"attempts":  gorm.Expr("attempts + 1"),
+				"locked_by": worker,
+				"locked_at": now,
+			}).Error
+	})
+	if err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	return jobs, nil
+}
+
+func (r *JobRepository) CompleteJob(ctx context.Context, id uint) *common.Error {
+	now := time.Now()
+	return r.finishJob(ctx, id, map[string]interface{}{
+		"status":       model.JobStatusDone,
+		"completed_at": now,
+		"last_error":   "",
+	})
+}
+
+// RetryJob puts a job whose run failed back in the queue to run at runAt.
+func (r *JobRepository) RetryJob(ctx context.Context, id uint, runAt time.Time, lastError string) *common.Error {
+	return r.finishJob(ctx, id, map[string]interface{}{
+		"status":     model.JobStatusPending,
+		"run_at":     runAt,
+		"last_error": lastError,
+	})
+}
+
+// FailJob gives up on a job for good.
+func (r *JobRepository) FailJob(ctx context.Context, id uint, lastError string) *common.Error {
+	now := time.Now()
+	return r.finishJob(ctx, id, map[string]interface{}{
+		"status":       model.JobStatusFailed,
+		"completed_at": now,
+		"last_error":   lastError,
+	})
+}
+
+// RequeueStaleJobs returns jobs that have been running since before
+// lockedBefore to the queue. Their worker stopped without reporting back,
+// usually because the process was killed mid-run. Jobs that already used up
+// their attempts are failed instead. It reports how many jobs were requeued
+// and how many failed.
+func (r *JobRepository) RequeueStaleJobs(ctx context.Context, lockedBefore time.Time) (requeued, failed int64, errRepo *common.Error) {
+	const lastError = "worker stopped while running the job"
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&model.Job{}).
+			Where("status = ? AND locked_at < ? AND attempts >= max_attempts", model.JobStatusRunning, lockedBefore).
+			Updates(map[string]interface{}{
+				"status":       model.JobStatusFailed,
+				"completed_at": now,
+				"locked_by":    "",
+				"locked_at":    nil,
+				"last_error":   lastError,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		failed = result.RowsAffected
+
+		result = tx.Model(&model.Job{}).
+			Where("status = ? AND locked_at < ?", model.JobStatusRunning, lockedBefore).
+			Updates(map[string]interface{}{
+				"status":     model.JobStatusPending,
+				"run_at":     now,
+				"locked_by":  "",
+				"locked_at":  nil,
+				"last_error": lastError,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		requeued = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, 0, r.returnError(ctx, err)
+	}
+	return requeued, failed, nil
+}
+
+func (r *JobRepository) finishJob(ctx context.Context, id uint, updates map[string]interface{}) *common.Error {
+	updates["locked_by"] = ""
+	updates["locked_at"] = nil
+	if err := r.db.WithContext(ctx).
+		Model(&model.Job{}).
+		Where("id = ?", id).
+		Updates(updates).Error; err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
@@ -2,10 +2,18 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/utils"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepository struct {
@@ -18,9 +26,26 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	}
 }
 
-func (r *OrderRepository) CreateOrder(ctx context.Context, order *model.Order) *common.Error {
-	if err := r.db.Create(order).Error; err != nil {
-		return common.ErrSystemError(ctx, err.Error())
+// CreateOrder saves the order and decrements the stock of every variant it
+// references in one transaction. Variant rows are locked while being checked,
+// so concurrent orders cannot both take the last items; the voucher row is
+// locked the same way while its usage limits are checked. The initial status
+// is recorded as the first event of the order's timeline.
+func (r *OrderRepository) CreateOrder(ctx context.Context, order *model.Order, event *model.OrderEvent) *common.Error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.reserveStock(ctx, tx, order.OrderItems); err != nil {
+			return err
+		}
+		if err := r.redeemVoucher(ctx, tx, order); err != nil {
+			return err
+		}
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		return r.recordEvent(tx, order.ID, "", order.Status, event)
+	})
+	if err != nil {
+		return r.returnTxError(ctx, err)
 	}
 	return nil
 }
@@ -38,7 +63,10 @@ func (r *OrderRepository) GetOrderByID(ctx context.Context, id string) (*model.O
 
 func (r *OrderRepository) GetOrder(ctx context.Context, id string) (*model.Order, *common.Error) {
 	var order model.Order
-	if err := r.db.Where("id = ?", id).Preload("OrderItems").First(&order).Error; err != nil {
+	if err := r.db.Where("id = ?", id).
+		Preload("OrderItems").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC, id ASC") }).
+		First(&order).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, common.ErrNotFound(ctx, "Order", "not found")
 		}
@@ -47,28 +75,832 @@ func (r *OrderRepository) GetOrder(ctx context.Context, id string) (*model.Order
 	return &order, nil
 }
 
-func (r *OrderRepository) ListOrders(ctx context.Context, offset int, limit int) ([]*model.Order, int64, *common.Error) {
-	var orders []*model.Order
+// OrderFilter narrows the admin order list. Zero values leave a criterion
+// out. SortBy takes a key of orderSortColumns, never a raw column name.
+type OrderFilter struct {
+	Statuses      []model.OrderStatus
+	PaymentMethod string
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	MinTotal      *decimal.Decimal
+	MaxTotal      *decimal.Decimal
+	Search        string
+	SortBy        string
+	SortDesc      bool
+}
+
+// orderSortColumns maps the sort keys accepted from clients to columns.
+var orderSortColumns = map[string]string{
+	"created_at":   "created_at",
+	"total":        "total_amount",
+	"total_amount": "total_amount",
+}
+
+func (r *OrderRepository) ListOrders(ctx context.Context, filter *OrderFilter, offset int, limit int) ([]*model.Order, int64, *common.Error) {
+	sortColumn := "created_at"
+	if filter.SortBy != "" {
+		column, ok := orderSortColumns[filter.SortBy]
+		if !ok {
+			return nil, 0, common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("cannot sort orders by %q", filter.SortBy))
+		}
+		sortColumn = column
+	}
+
+	query := r.db.WithContext(ctx).Model(&model.Order{}).Scopes(orderFilterScope(filter))
+
 	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, r.returnError(ctx, err)
+	}
+
+	var orders []*model.Order
+	if err := query.Preload("OrderItems").
+		Offset(offset).
+		Limit(limit).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: sortColumn}, Desc: filter.SortDesc}).
+		Order("id").
+		Find(&orders).Error; err != nil {
+		return nil, 0, r.returnError(ctx, err)
+	}
+
+	return orders, total, nil
+}
+
+// orderFilterScope turns an OrderFilter into conditions. Every value is bound
+// as a parameter; search terms are matched literally.
+func orderFilterScope(filter *OrderFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(filter.Statuses) > 0 {
+			db = db.Where("status IN ?", filter.Statuses)
+		}
+		if filter.PaymentMethod != "" {
+			db = db.Where("payment_method = ?", filter.PaymentMethod)
+		}
+		if filter.CreatedFrom != nil {
+			db = db.Where("created_at >= ?", *filter.CreatedFrom)
+		}
+		if filter.CreatedTo != nil {
+			db = db.Where("created_at < ?", *filter.CreatedTo)
+		}
+		if filter.MinTotal != nil {
+			db = db.Where("total_amount >= ?", *filter.MinTotal)
+		}
+		if filter.MaxTotal != nil {
+			db = db.Where("total_amount <= ?", *filter.MaxTotal)
+		}
+		if search := strings.TrimSpace(filter.Search); search != "" {
+			pattern := "%" + escapeLike(search) + "%"
+			conditions := db.Session(&gorm.Session{NewDB: true}).
+				Where("id ILIKE ?", pattern).
+				Or("transaction_id ILIKE ?", pattern).
+				Or("zalo_order_id ILIKE ?", pattern).
+				Or("customer_info->>'name' ILIKE ?", pattern).
+				Or("customer_info->>'phone' ILIKE ?", pattern)
+			if phones := utils.PhoneVariants(search); len(phones) > 1 {
+				conditions = conditions.Or("customer_info->>'phone' IN ?", phones)
+			}
+			db = db.Where(conditions)
+		}
+		return db
+	}
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ListOrdersCreatedBetween returns every order created in [from, to), oldest
+// first.
+func (r *OrderRepository) ListOrdersCreatedBetween(ctx context.Context, from, to time.Time) ([]*model.Order, *common.Error) {
+	var orders []*model.Order
+	if err := r.db.WithContext(ctx).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at ASC, id ASC").
+		Find(&orders).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	return orders, nil
+}
+
+// customerScope limits a query to the orders placed by a Zalo user or under
+// one of the given phone numbers.
+func customerScope(zaloUserID string, phones []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(phones) == 0 {
+			return db.Where("zalo_user_id = ?", zaloUserID)
+		}
+		return db.Where("zalo_user_id = ? OR customer_info->>'phone' IN ?", zaloUserID, phones)
+	}
+}
+
+func (r *OrderRepository) ListCustomerOrders(ctx context.Context, zaloUserID string, phones []string, status *model.OrderStatus, offset int, limit int) ([]*model.Order, int64, *common.Error) {
+	query := r.db.WithContext(ctx).Model(&model.Order{}).Scopes(customerScope(zaloUserID, phones))
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
 
-	if err := r.db.Model(&model.Order{}).Count(&total).Error; err != nil {
-		return nil, 0, common.ErrSystemError(ctx, err.Error())
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, r.returnError(ctx, err)
 	}
 
-	if err := r.db.Preload("OrderItems").
+	var orders []*model.Order
+	if err := query.Preload("OrderItems").
 		Offset(offset).
 		Limit(limit).
 		Order("created_at desc").
 		Find(&orders).Error; err != nil {
-		return nil, 0, common.ErrSystemError(ctx, err.Error())
+		return nil, 0, r.returnError(ctx, err)
 	}
 
 	return orders, total, nil
 }
 
-func (r *OrderRepository) UpdateOrder(ctx context.Context, order *model.Order) *common.Error {
-	if err := r.db.Save(order).Error; err != nil {
-		return common.ErrSystemError(ctx, err.Error())
+func (r *OrderRepository) GetCustomerOrder(ctx context.Context, id string, zaloUserID string, phones []string) (*model.Order, *common.Error) {
+	var order model.Order
+	if err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		Scopes(customerScope(zaloUserID, phones)).
+		Preload("OrderItems").
+		First(&order).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, common.ErrNotFound(ctx, "Order", "not found")
+		}
+		return nil, r.returnError(ctx, err)
+	}
+	return &order, nil
+}
+
+// UpdateOrder saves the given columns of the order, the ones the caller
+// changed, so columns other writers set meanwhile, such as the paid amount,
+// are left alone. A status change is checked against the order lifecycle
+// while the row is locked and recorded in the order's timeline with the
+// source, actor and reference given in event. When the order moves into a
+// status that gives up its reservation, the reserved variant stock and voucher
+// usage are returned in the same transaction.
+func (r *OrderRepository) UpdateOrder(ctx context.Context, order *model.Order, event *model.OrderEvent, columns ...string) *common.Error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current model.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status").
+			Where("id = ?", order.ID).
+			Take(&current).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return common.ErrNotFound(ctx, "Order", "not found")
+			}
+			return err
+		}
+
+		if !slices.Contains(columns, "status") {
+			order.Status = current.Status
+		}
+		if current.Status != order.Status && !current.Status.CanTransitionTo(order.Status) {
+			return common.ErrInvalidTransition(ctx, "Order", string(current.Status), string(order.Status))
+		}
+
+		if order.Status.ReleasesStock() && !current.Status.ReleasesStock() {
+			if err := r.releaseStock(tx, order.ID); err != nil {
+				return err
+			}
+			if err := r.releaseVoucher(tx, order.ID); err != nil {
+				return err
+			}
+		}
+
+		if len(columns) > 0 {
+			if err := tx.Model(order).Select(columns).Updates(order).Error; err != nil {
+				return err
+			}
+		}
+
+		if current.Status == order.Status {
+			return nil
+		}
+		return r.recordEvent(tx, order.ID, current.Status, order.Status, event)
+	})
+	if err != nil {
+		return r.returnTxError(ctx, err)
+	}
+	return nil
+}
+
+// CreateRefund records a refund against the order while the order row is
+// locked. Refunds are limited to what the customer paid, or the order total
+// when that is more, and a zero amount refunds whatever is left. When the
+// refunds reach that limit the order moves to refunded, recorded with event,
+// and report is queued in the same transaction so the refund is never left
+// unreported.
+func (r *OrderRepository) CreateRefund(ctx context.Context, refund *model.Refund, event *model.OrderEvent, report *model.Job) (*model.Order, *common.Error) {
+	var order model.Order
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", refund.OrderID).
+			Take(&order).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return common.ErrNotFound(ctx, "Order", "not found")
+			}
+			return err
+		}
+
+		var refunded decimal.Decimal
+		if err := tx.Model(&model.Refund{}).
+			Where("order_id = ?", order.ID).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&refunded).Error; err != nil {
+			return err
+		}
+
+		remaining := decimal.Max(order.PaidAmount, order.TotalAmount).Sub(refunded)
+		if refund.Amount.IsZero() {
+			refund.Amount = remaining
+		}
+		if !refund.Amount.IsPositive() || refund.Amount.GreaterThan(remaining) {
+			return common.ErrConflict(ctx, "Refund", fmt.Sprintf("amount must be between 0 and the remaining %s", remaining.StringFixed(0)))
+		}
+
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+
+		if refund.Amount.LessThan(remaining) {
+			return nil
+		}
+
+		if !order.Status.CanTransitionTo(model.OrderStatusRefunded) {
+			return common.ErrInvalidTransition(ctx, "Order", string(order.Status), string(model.OrderStatusRefunded))
+		}
+		from := order.Status
+		order.Status = model.OrderStatusRefunded
+		if err := tx.Model(&order).Update("status", order.Status).Error; err != nil {
+			return err
+		}
+		if err := r.recordEvent(tx, order.ID, from, order.Status, event); err != nil {
+			return err
+		}
+		return tx.Create(report).Error
+	})
+	if err != nil {
+		return nil, r.returnTxError(ctx, err)
+	}
+	return &order, nil
+}
+
+// ApplyBankTransfer adds a received bank transaction to the order's paid
+// amount while the order row is locked. orderID, as found by
+// utils.ExtractOrderID, is looked up as written and then upper-cased, so
+// orders made before IDs were upper case still match. An order waiting for
+// payment moves to pending, recorded with event, once the transfers cover its
+// total. The transaction is linked to the order in the same database
+// transaction, and one that was already processed fails with a conflict, so a
+// transfer is never counted twice. It reports whether this transfer settled
+// the order.
+func (r *OrderRepository) ApplyBankTransfer(ctx context.Context, txn *model.BankTransaction, orderID string, event *model.OrderEvent) (*model.Order, bool, *common.Error) {
+	var order model.Order
+	settled := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.BankTransaction{}).
+			Where("id = ? AND status = ?", txn.ID, model.BankTransactionStatusReceived).
+			Update("status", model.BankTransactionStatusApplied)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return common.ErrConflict(ctx, "Bank transaction", "was already processed")
+		}
+
+		found := false
+		for _, id := range utils.OrderIDCandidates(orderID) {
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", id).
+				Take(&order).Error
+			if err == nil {
+				found = true
+				break
+			}
+			if err != gorm.ErrRecordNotFound {
+				return err
+			}
+		}
+		if !found {
+			return common.ErrNotFound(ctx, "Order", "not found")
+		}
+
+		if err := tx.Model(&model.BankTransaction{}).
+			Where("id = ?", txn.ID).
+			Update("order_id", order.ID).Error; err != nil {
+			return err
+		}
+		txn.Status = model.BankTransactionStatusApplied
+		txn.OrderID = &order.ID
+
+		reference := fmt.Sprintf("%d", txn.ProviderID)
+		if err := r.recordPayment(tx, &order, &model.Payment{
+			Method:        "BANK",
+			Amount:        txn.Amount,
+			Status:        model.PaymentAttemptSucceeded,
+			TransactionID: &reference,
+			Payload:       txn.Payload,
+		}); err != nil {
+			return err
+		}
+
+		from := order.Status
+		if from != model.OrderStatusPaying || order.PaidAmount.LessThan(order.TotalAmount) {
+			return nil
+		}
+		order.Status = model.OrderStatusPending
+		settled = true
+		if err := tx.Model(&order).Update("status", order.Status).Error; err != nil {
+			return err
+		}
+		return r.recordEvent(tx, order.ID, from, order.Status, event)
+	})
+	if err != nil {
+		return nil, false, r.returnTxError(ctx, err)
+	}
+	return &order, settled, nil
+}
+
+// LinkZaloOrder records the Zalo order and payment method the customer chose
+// for the order while its row is locked, and opens a pending payment attempt
+// for them; pending attempts of other methods were abandoned and are marked
+// failed. Only an order waiting for payment may be linked, or one never linked
+// before that still has the method it was created with, as COD orders start
+// pending. In the same transaction a paying order then moves to next, recorded
+// with event, or when next is paying, check is queued to follow the payment
+// up. It reports false when the order was already linked to that Zalo order
+// and method, in which case nothing changes.
+func (r *OrderRepository) LinkZaloOrder(ctx context.Context, orderID, zaloOrderID, method string, next model.OrderStatus, event *model.OrderEvent, check *model.Job) (*model.Order, bool, *common.Error) {
+	var order model.Order
+	linked := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", orderID).
+			Take(&order).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return common.ErrNotFound(ctx, "Order", "not found")
+			}
+			return err
+		}
+		if order.ZaloOrderID != nil && *order.ZaloOrderID == zaloOrderID && order.PaymentMethod == method {
+			return nil
+		}
+		firstLink := order.ZaloOrderID == nil && order.PaymentMethod == method
+		if order.Status != model.OrderStatusPaying && !firstLink {
+			return common.ErrConflict(ctx, "Order", fmt.Sprintf("cannot be linked to a Zalo order once %s", order.Status))
+		}
+
+		linked = true
+		order.ZaloOrderID = &zaloOrderID
+		order.PaymentMethod = method
+		if err := tx.Model(&order).Updates(map[string]interface{}{
+			"zalo_order_id":  zaloOrderID,
+			"payment_method": method,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.Payment{}).
+			Where("order_id = ? AND status = ? AND method <> ?", order.ID, model.PaymentAttemptPending, method).
+			Updates(map[string]interface{}{
+				"status":       model.PaymentAttemptFailed,
+				"completed_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		if err := r.recordPayment(tx, &order, &model.Payment{
+			Method:      method,
+			Amount:      order.TotalAmount,
+			Status:      model.PaymentAttemptPending,
+			ZaloOrderID: &zaloOrderID,
+		}); err != nil {
+			return err
+		}
+
+		switch {
+		case order.Status != model.OrderStatusPaying:
+			return nil
+		case next == model.OrderStatusPaying:
+			return tx.Create(check).Error
+		default:
+			return r.moveOrder(ctx, tx, &order, next, event)
+		}
+	})
+	if err != nil {
+		return nil, false, r.returnTxError(ctx, err)
+	}
+	return &order, linked, nil
+}
+
+// RecordCodDelivery records how delivery of a COD order ended while the order
+// row is locked: the order moves to next, recorded with event, payment is
+// saved as its COD attempt and report is queued to tell Zalo, all in one
+// transaction. Recording an outcome that is already on file changes nothing.
+func (r *OrderRepository) RecordCodDelivery(ctx context.Context, order *model.Order, next model.OrderStatus, payment *model.Payment, event *model.OrderEvent, report *model.Job) *common.Error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current model.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", order.ID).
+			Take(&current).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return common.ErrNotFound(ctx, "Order", "not found")
+			}
+			return err
+		}
+
+		// An order completed without its payment on file was confirmed before
+		// the two were saved together; it gets the payment and report now
+		recorded := current.Status == next &&
+			(next == model.OrderStatusFailed || !current.PaidAmount.LessThan(current.TotalAmount))
+		if !recorded {
+			if current.Status != next {
+				if err := r.moveOrder(ctx, tx, &current, next, event); err != nil {
+					return err
+				}
+			}
+			if err := r.recordPayment(tx, &current, payment); err != nil {
+				return err
+			}
+			if err := tx.Create(report).Error; err != nil {
+				return err
+			}
+		}
+
+		order.Status = current.Status
+		order.PaidAmount = current.PaidAmount
+		order.PaymentStatus = current.PaymentStatus
+		return nil
+	})
+	if err != nil {
+		return r.returnTxError(ctx, err)
+	}
+	return nil
+}
+
+// SettleOrderPayment records a payment result reported for the order and,
+// when the order is still waiting for payment, moves it to pending on success
+// or to failed otherwise, all while the order row is locked so the attempt is
+// never counted without the status following it. A success that repeats an
+// attempt already recorded as succeeded, matched by provider transaction ID or
+// Zalo order ID, is not counted again; any other success is, even when the
+// order's payments already cover it, and the order then shows as overpaid. An
+// order that already left paying keeps its status, but still takes the
+// provider transaction ID if it has none yet.
+func (r *OrderRepository) SettleOrderPayment(ctx context.Context, order *model.Order, payment *model.Payment, event *model.OrderEvent) *common.Error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current model.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", order.ID).
+			Take(&current).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return common.ErrNotFound(ctx, "Order", "not found")
+			}
+			return err
+		}
+
+		if payment.TransactionID != nil && (current.TransactionID == nil || *current.TransactionID == "") {
+			current.TransactionID = payment.TransactionID
+			if err := tx.Model(&current).Update("transaction_id", current.TransactionID).Error; err != nil {
+				return err
+			}
+		}
+
+		succeeded := payment.Status == model.PaymentAttemptSucceeded
+		repeated := false
+		if succeeded {
+			var err error
+			if repeated, err = r.paymentRecorded(tx, current.ID, payment); err != nil {
+				return err
+			}
+		}
+		if !repeated {
+			if err := r.recordPayment(tx, &current, payment); err != nil {
+				return err
+			}
+		}
+
+		if current.Status == model.OrderStatusPaying {
+			next := model.OrderStatusFailed
+			if succeeded {
+				next = model.OrderStatusPending
+			}
+			if err := r.moveOrder(ctx, tx, &current, next, event); err != nil {
+				return err
+			}
+		}
+
+		order.Status = current.Status
+		order.TransactionID = current.TransactionID
+		order.PaidAmount = current.PaidAmount
+		order.PaymentStatus = current.PaymentStatus
+		return nil
+	})
+	if err != nil {
+		return r.returnTxError(ctx, err)
+	}
+	return nil
+}
+
+func (r *OrderRepository) ListRefunds(ctx context.Context, orderID string) ([]*model.Refund, *common.Error) {
+	var refunds []*model.Refund
+	if err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC, id ASC").
+		Find(&refunds).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	return refunds, nil
+}
+
+// MarkRefundsReported marks every refund of the order as reported to Zalo.
+func (r *OrderRepository) MarkRefundsReported(ctx context.Context, orderID string) *common.Error {
+	if err := r.db.WithContext(ctx).
+		Model(&model.Refund{}).
+		Where("order_id = ? AND zalo_reported = ?", orderID, false).
+		Update("zalo_reported", true).Error; err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
+
+// paymentRecorded reports whether the order already has a succeeded attempt
+// with the provider transaction ID or Zalo order ID of payment.
+func (r *OrderRepository) paymentRecorded(tx *gorm.DB, orderID string, payment *model.Payment) (bool, error) {
+	if payment.TransactionID == nil && payment.ZaloOrderID == nil {
+		return false, nil
+	}
+	query := tx.Model(&model.Payment{}).Where("order_id = ? AND status = ?", orderID, model.PaymentAttemptSucceeded)
+	switch {
+	case payment.TransactionID != nil && payment.ZaloOrderID != nil:
+		query = query.Where("transaction_id = ? OR zalo_order_id = ?", *payment.TransactionID, *payment.ZaloOrderID)
+	case payment.TransactionID != nil:
+		query = query.Where("transaction_id = ?", *payment.TransactionID)
+	default:
+		query = query.Where("zalo_order_id = ?", *payment.ZaloOrderID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// recordPayment saves payment as an attempt of the locked order. A result for
+// an attempt already on file, matched by provider transaction ID or else by the
+// latest pending attempt of the same method, updates that attempt; anything
+// else adds one. The order's paid amount and payment status are then derived
+// from the succeeded attempts.
+func (r *OrderRepository) recordPayment(tx *gorm.DB, order *model.Order, payment *model.Payment) error {
+	payment.OrderID = order.ID
+	if payment.Status != model.PaymentAttemptPending && payment.CompletedAt == nil {
+		now := time.Now()
+		payment.CompletedAt = &now
+	}
+
+	var existing model.Payment
+	err := gorm.ErrRecordNotFound
+	if payment.TransactionID != nil {
+		err = tx.Where("order_id = ? AND transaction_id = ?", order.ID, *payment.TransactionID).
+			Take(&existing).Error
+	}
+	if err == gorm.ErrRecordNotFound {
+		err = tx.Where("order_id = ? AND method = ? AND status = ?", order.ID, payment.Method, model.PaymentAttemptPending).
+			Order("id DESC").
+			Take(&existing).Error
+	}
+	switch err {
+	case nil:
+		payment.ID = existing.ID
+		payment.CreatedAt = existing.CreatedAt
+		if payment.ZaloOrderID == nil {
+			payment.ZaloOrderID = existing.ZaloOrderID
+		}
+		if err := tx.Save(payment).Error; err != nil {
+			return err
+		}
+	case gorm.ErrRecordNotFound:
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	var payments []model.Payment
+	if err := tx.Where("order_id = ?", order.ID).Find(&payments).Error; err != nil {
+		return err
+	}
+	order.PaidAmount = model.PaidAmount(payments)
+	order.PaymentStatus = model.PaymentStatusFor(order.PaidAmount, order.TotalAmount)
+	return tx.Model(order).Updates(map[string]interface{}{
+		"paid_amount":    order.PaidAmount,
+		"payment_status": order.PaymentStatus,
+	}).Error
+}
+
+// moveOrder changes the status of the locked order to next, returning its
+// reserved stock and voucher usage when next gives them up, and records the
+// change with event.
+func (r *OrderRepository) moveOrder(ctx context.Context, tx *gorm.DB, order *model.Order, next model.OrderStatus, event *model.OrderEvent) error {
+	if !order.Status.CanTransitionTo(next) {
+		return common.ErrInvalidTransition(ctx, "Order", string(order.Status), string(next))
+	}
+
+	if next.ReleasesStock() && !order.Status.ReleasesStock() {
+		if err := r.releaseStock(tx, order.ID); err != nil {
+			return err
+		}
+		if err := r.releaseVoucher(tx, order.ID); err != nil {
+			return err
+		}
+	}
+
+	from := order.Status
+	order.Status = next
+	if err := tx.Model(order).Update("status", next).Error; err != nil {
+		return err
+	}
+	return r.recordEvent(tx, order.ID, from, next, event)
+}
+
+// reserveStock locks the referenced variants in id order and decrements their
+// stock, failing with an out-of-stock error naming the first item that cannot
+// be fulfilled.
+func (r *OrderRepository) reserveStock(ctx context.Context, tx *gorm.DB, items []model.OrderItem) error {
+	quantities, names := make(map[uint]int64), make(map[uint]string)
+	for _, item := range items {
+		if item.VariantID == nil {
+			continue
+		}
+		quantities[*item.VariantID] += int64(item.Quantity)
+		if item.ProductSnapshot != nil {
+			names[*item.VariantID] = fmt.Sprintf("%s (%s)", item.ProductSnapshot.Name, item.ProductSnapshot.VariantName)
+		}
+	}
+	if len(quantities) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var variants []model.ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&variants).Error; err != nil {
+		return err
+	}
+	if len(variants) != len(ids) {
+		return common.ErrNotFound(ctx, "Product variant", "not found")
+	}
+
+	for _, variant := range variants {
+		if variant.Stock < quantities[variant.ID] {
+			name, ok := names[variant.ID]
+			if !ok {
+				name = variant.Name
+			}
+			return common.ErrOutOfStock(ctx, name, variant.Stock)
+		}
+		if err := tx.Model(&model.ProductVariant{}).
+			Where("id = ?", variant.ID).
+			UpdateColumn("stock", gorm.Expr("stock - ?", quantities[variant.ID])).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseStock returns the quantities reserved by the order's items to their
+// variants, archived ones included so their stock is right once restored.
+func (r *OrderRepository) releaseStock(tx *gorm.DB, orderID string) error {
+	var items []model.OrderItem
+	if err := tx.Where("order_id = ? AND variant_id IS NOT NULL", orderID).
+		Order("variant_id").
+		Find(&items).Error; err != nil {
+		return err
 	}
+
+	for _, item := range items {
+		if err := tx.Unscoped().Model(&model.ProductVariant{}).
+			Where("id = ?", *item.VariantID).
+			UpdateColumn("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// redeemVoucher counts the order against its voucher's global and per-phone
+// usage limits. The voucher row stays locked until the order is saved, so
+// concurrent checkouts cannot both take the last use.
+func (r *OrderRepository) redeemVoucher(ctx context.Context, tx *gorm.DB, order *model.Order) error {
+	if order.VoucherID == nil {
+		return nil
+	}
+
+	var voucher model.Voucher
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", *order.VoucherID).
+		Take(&voucher).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return common.ErrNotFound(ctx, "Voucher", "not found")
+		}
+		return err
+	}
+
+	if voucher.UsageLimit != nil && voucher.UsedCount >= *voucher.UsageLimit {
+		return common.ErrVoucherNotApplicable(ctx, voucher.Code, "usage limit reached")
+	}
+
+	var phone string
+	if order.CustomerInfo != nil {
+		phone = utils.LocalPhone(order.CustomerInfo.Phone)
+	}
+	if voucher.PerPhoneLimit != nil {
+		var used int64
+		if err := tx.Model(&model.VoucherUsage{}).
+			Where("voucher_id = ? AND phone = ?", voucher.ID, phone).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if used >= int64(*voucher.PerPhoneLimit) {
+			return common.ErrVoucherNotApplicable(ctx, voucher.Code, "already used the maximum number of times for this phone number")
+		}
+	}
+
+	if err := tx.Model(&model.Voucher{}).
+		Where("id = ?", voucher.ID).
+		UpdateColumn("used_count", gorm.Expr("used_count + 1")).Error; err != nil {
+		return err
+	}
+	return tx.Create(&model.VoucherUsage{
+		VoucherID: voucher.ID,
+		OrderID:   order.ID,
+		Phone:     phone,
+		Discount:  order.DiscountAmount,
+	}).Error
+}
+
+// releaseVoucher gives the order's voucher use back, so a failed or cancelled
+// order does not count against the voucher's limits.
+func (r *OrderRepository) releaseVoucher(tx *gorm.DB, orderID string) error {
+	var usage model.VoucherUsage
+	result := tx.Where("order_id = ?", orderID).Limit(1).Find(&usage)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	if err := tx.Delete(&usage).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.Voucher{}).
+		Where("id = ? AND used_count > 0", usage.VoucherID).
+		UpdateColumn("used_count", gorm.Expr("used_count - 1")).Error
+}
+
+func (r *OrderRepository) ListOrderEvents(ctx context.Context, orderID string) ([]*model.OrderEvent, *common.Error) {
+	var events []*model.OrderEvent
+	if err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC, id ASC").
+		Find(&events).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	return events, nil
+}
+
+// HasTransition reports whether the order's timeline contains a move from one
+// status to another.
+func (r *OrderRepository) HasTransition(ctx context.Context, orderID string, from, to model.OrderStatus) (bool, *common.Error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&model.OrderEvent{}).
+		Where("order_id = ? AND from_status = ? AND to_status = ?", orderID, from, to).
+		Count(&count).Error; err != nil {
+		return false, r.returnError(ctx, err)
+	}
+	return count > 0, nil
+}
+
+// recordEvent appends a status change to the order's timeline. Events without
+// a known source are attributed to the system.
+func (r *OrderRepository) recordEvent(tx *gorm.DB, orderID string, from, to model.OrderStatus, event *model.OrderEvent) error {
+	record := model.OrderEvent{Source: model.OrderEventSourceSystem}
+	if event != nil {
+		record = *event
+	}
+	record.ID = 0
+	record.OrderID = orderID
+	record.FromStatus = from
+	record.ToStatus = to
+
+	return tx.Create(&record).Error
+}
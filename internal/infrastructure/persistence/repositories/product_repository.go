@@ -3,6 +3,9 @@ package repositories
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
@@ -23,7 +26,8 @@ func (r *ProductRepository) CreateProduct(ctx context.Context, product *model.Pr
 	return r.returnError(ctx, r.db.WithContext(ctx).Create(product).Error)
 }
 
-// IsExistProduct checks if a product with the same name already exists
+// IsExistProduct checks if a product with the same name already exists,
+// archived products included since they keep their name
 func (r *ProductRepository) IsExistProduct(ctx context.Context, name string) (bool, *common.Error) {
 	var count int64
 
@@ -32,6 +36,7 @@ func (r *ProductRepository) IsExistProduct(ctx context.Context, name string) (bo
 	}
 
 	err := r.db.WithContext(ctx).
+		Unscoped().
 		Model(&model.Product{}).
 		Clauses(conds...).
 		Count(&count).
@@ -76,6 +81,7 @@ func (r *ProductRepository) GetProductDetailByID(ctx context.Context, id uint) (
 	if err := r.db.WithContext(ctx).
 		Preload("Variants").
 		Preload("ProductImages.Image").
+		Preload("Tags").
 		First(&prod, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, common.ErrNotFound(ctx, "Product", "not found")
@@ -86,43 +92,178 @@ func (r *ProductRepository) GetProductDetailByID(ctx context.Context, id uint) (
 	return &prod, nil
 }
 
-// ListProducts with pagination, always includes variants
-func (r *ProductRepository) ListProducts(ctx context.Context, offset, limit int) ([]*model.Product, int64, *common.Error) {
-	// Count
-	var total int64
+// GetProductBySlug loads a product with its details by its current slug.
+func (r *ProductRepository) GetProductBySlug(ctx context.Context, slug string) (*model.Product, *common.Error) {
+	var prod model.Product
+	if err := r.db.WithContext(ctx).
+		Preload("Variants").
+		Preload("ProductImages.Image").
+		Preload("Tags").
+		Where("slug = ?", slug).
+		First(&prod).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound(ctx, "Product", "not found")
+		}
+		return nil, r.returnError(ctx, err)
+	}
+
+	return &prod, nil
+}
+
+// GetRedirectedSlug returns the current slug of the product that was known
+// by slug before. It is not found when no product had the slug, or the
+// product is gone, archived or not on the storefront at time at.
+func (r *ProductRepository) GetRedirectedSlug(ctx context.Context, slug string, at time.Time) (string, *common.Error) {
+	var current []string
+	if err := r.db.WithContext(ctx).
+		Model(&model.ProductSlugRedirect{}).
+		Joins("JOIN products ON products.id = product_slug_redirects.product_id AND products.deleted_at IS NULL").
+		Where("product_slug_redirects.slug = ?", slug).
+		Where(productVisible, productVisibleArgs(at)...).
+		Limit(1).
+		Pluck("products.slug", &current).Error; err != nil {
+		return "", r.returnError(ctx, err)
+	}
+	if len(current) == 0 {
+		return "", common.ErrNotFound(ctx, "Product", "not found")
+	}
+	return current[0], nil
+}
+
+// IsSlugTaken reports whether a product other than excludeID has slug.
+// Slugs only left in another product's redirect history can be reused;
+// archived products keep theirs for when they are restored.
+func (r *ProductRepository) IsSlugTaken(ctx context.Context, slug string, excludeID uint) (bool, *common.Error) {
+	var count int64
 	if err := r.db.WithContext(ctx).
+		Unscoped().
 		Model(&model.Product{}).
-		Count(&total).Error; err != nil {
-		return nil, 0, r.returnError(ctx, err)
+		Where("slug = ? AND id <> ?", slug, excludeID).
+		Count(&count).Error; err != nil {
+		return false, r.returnError(ctx, err)
 	}
+	return count > 0, nil
+}
 
+// GetProductsByIDs loads the given products with their variants and main
+// image. Missing IDs are simply absent from the result.
+func (r *ProductRepository) GetProductsByIDs(ctx context.Context, ids []uint) ([]*model.Product, *common.Error) {
 	var products []*model.Product
+	if len(ids) == 0 {
+		return products, nil
+	}
+
 	if err := r.db.WithContext(ctx).
+		Preload("Variants").
 		Preload("ProductImages", "is_main = ?", true).
 		Preload("ProductImages.Image").
-		Offset(offset).
-		Limit(limit).
+		Where("id IN ?", ids).
 		Find(&products).Error; err != nil {
-		return nil, 0, r.returnError(ctx, err)
+		return nil, r.returnError(ctx, err)
 	}
 
-	return products, total, nil
+	return products, nil
 }
 
-func (r *ProductRepository) GetProductsByCategoryID(ctx context.Context, categoryID uint, offset, limit int) ([]*model.Product, int64, *common.Error) {
+// productSearchDocument is the text search document of a product, with the
+// name ranked above the description. It must match the expression of the
+// idx_products_search index for the index to be used.
+const productSearchDocument = `setweight(to_tsvector('simple', immutable_unaccent(products.name)), 'A') || ` +
+	`setweight(to_tsvector('simple', immutable_unaccent(COALESCE(products.description, ''))), 'B')`
+
+// productSearchName is the product name as compared by trigram similarity,
+// matching the idx_products_name_trgm index.
+const productSearchName = `lower(immutable_unaccent(products.name))`
+
+// productVisible holds for products on the storefront, as Product.IsVisibleAt
+// decides, given the arguments of productVisibleArgs. It reads the schedule
+// rather than trusting Status alone, which the publication job only updates
+// once it runs.
+const productVisible = `(products.status = ? OR (products.status = ? AND products.publish_at <= ?)) ` +
+	`AND (products.unpublish_at IS NULL OR products.unpublish_at > ?)`
+
+func productVisibleArgs(at time.Time) []interface{} {
+	return []interface{}{model.ProductStatusPublished, model.ProductStatusScheduled, at, at}
+}
+
+// productHasVariants holds for products with a variant that is not archived.
+const productHasVariants = `EXISTS (SELECT 1 FROM product_variants ` +
+	`WHERE product_variants.product_id = products.id AND product_variants.deleted_at IS NULL)`
+
+// productSalesJoin adds the quantity of each product sold, counting orders
+// that were paid for or are being paid on delivery, as sales.sold.
+const productSalesJoin = `LEFT JOIN (` +
+	`SELECT (order_items.product_snapshot ->> 'product_id')::bigint AS product_id, SUM(order_items.quantity) AS sold ` +
+	`FROM order_items JOIN orders ON orders.id = order_items.order_id ` +
+	`WHERE orders.status IN ? GROUP BY 1` +
+	`) AS sales ON sales.product_id = products.id`
+
+// soldOrderStatuses are the statuses of orders whose items count as sold.
+var soldOrderStatuses = []model.OrderStatus{
+	model.OrderStatusPending,
+	model.OrderStatusProcessing,
+	model.OrderStatusShipping,
+	model.OrderStatusCompleted,
+}
+
+// productSort is a way to order products and the direction it defaults to.
+type productSort struct {
+	expr string
+	desc bool
+}
+
+// productSorts maps the sort keys accepted from clients to orderings.
+var productSorts = map[string]productSort{
+	"price":        {expr: "products.price"},
+	"newest":       {expr: "products.created_at", desc: true},
+	"best_selling": {expr: "COALESCE(sales.sold, 0)", desc: true},
+}
+
+// ProductFilter narrows the catalog. Zero values leave a criterion out; a
+// category includes its descendants. VisibleAt keeps the products on the
+// storefront at that time, so only the admin leaves it out. Without SortBy,
+// products matching Query come by relevance and others newest first. SortBy
+// takes a key of productSorts, never a raw column name; SortOrder is asc,
+// desc or empty for the sort's own default.
+type ProductFilter struct {
+	Query       string
+	CategoryID  *uint
+	MinPrice    *int64
+	MaxPrice    *int64
+	InStock     bool
+	HasVariants *bool
+	Tag         string
+	VisibleAt   *time.Time
+	SortBy      string
+	SortOrder   string
+}
+
+// SearchProducts lists the products matching filter. Query matches the words
+// of the name or description ignoring Vietnamese diacritics, or a name close
+// to it so misspelled queries still match.
+func (r *ProductRepository) SearchProducts(ctx context.Context, filter *ProductFilter, offset, limit int) ([]*model.Product, int64, *common.Error) {
+	order, sales, err := productOrder(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := r.db.WithContext(ctx).Model(&model.Product{}).Scopes(productFilterScope(filter))
+
 	var total int64
-	if err := r.db.WithContext(ctx).
-		Model(&model.Product{}).
-		Where("category_id = ?", categoryID).
-		Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, r.returnError(ctx, err)
 	}
 
+	if sales {
+		query = query.Joins(productSalesJoin, soldOrderStatuses)
+	}
+
 	var products []*model.Product
-	if err := r.db.WithContext(ctx).
-		Where("category_id = ?", categoryID).
+	if err := query.
 		Preload("ProductImages", "is_main = ?", true).
 		Preload("ProductImages.Image").
+		Preload("Tags").
+		Order(order).
 		Offset(offset).
 		Limit(limit).
 		Find(&products).Error; err != nil {
@@ -132,30 +273,414 @@ func (r *ProductRepository) GetProductsByCategoryID(ctx context.Context, categor
 	return products, total, nil
 }
 
-// UpdateProduct updates only product fields (not variants)
+// productOrder returns the ordering for filter and whether it needs the
+// sales join. Products with equal keys are kept in a stable order by ID.
+func productOrder(ctx context.Context, filter *ProductFilter) (clause.OrderBy, bool, *common.Error) {
+	if filter.SortBy == "" && filter.Query != "" {
+		return clause.OrderBy{Expression: clause.Expr{
+			SQL: "ts_rank(" + productSearchDocument + ", plainto_tsquery('simple', immutable_unaccent(?))) DESC, " +
+				"word_similarity(lower(immutable_unaccent(?)), " + productSearchName + ") DESC, products.id",
+			Vars:               []interface{}{filter.Query, filter.Query},
+			WithoutParentheses: true,
+		}}, false, nil
+	}
+
+	key := filter.SortBy
+	if key == "" {
+		key = "newest"
+	}
+	sort, ok := productSorts[key]
+	if !ok {
+		return clause.OrderBy{}, false, common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("cannot sort products by %q", filter.SortBy))
+	}
+	switch strings.ToLower(filter.SortOrder) {
+	case "":
+	case "asc":
+		sort.desc = false
+	case "desc":
+		sort.desc = true
+	default:
+		return clause.OrderBy{}, false, common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("unknown sort order %q", filter.SortOrder))
+	}
+
+	direction := " ASC"
+	if sort.desc {
+		direction = " DESC"
+	}
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                sort.expr + direction + ", products.id",
+		WithoutParentheses: true,
+	}}, key == "best_selling", nil
+}
+
+// productFilterScope turns a ProductFilter into conditions. Every value is
+// bound as a parameter and every column qualified, so facet queries can join
+// other tables. The raw subqueries skip archived variants themselves, as gorm
+// only scopes the main model.
+func productFilterScope(filter *ProductFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.VisibleAt != nil {
+			db = db.Where(productVisible, productVisibleArgs(*filter.VisibleAt)...)
+		}
+		if filter.Query != "" {
+			db = db.Where("(("+productSearchDocument+") @@ plainto_tsquery('simple', immutable_unaccent(?)) OR "+
+				"lower(immutable_unaccent(?)) <% "+productSearchName+")", filter.Query, filter.Query)
+		}
+		if filter.CategoryID != nil {
+			// UNION rather than UNION ALL stops at a category seen before,
+			// should the tree ever contain a cycle
+			db = db.Where("products.category_id IN (WITH RECURSIVE subtree AS ("+
+				"SELECT id FROM categories WHERE id = ? "+
+				"UNION SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id"+
+				") SELECT id FROM subtree)", *filter.CategoryID)
+		}
+		if filter.MinPrice != nil {
+			db = db.Where("products.price >= ?", *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			db = db.Where("products.price <= ?", *filter.MaxPrice)
+		}
+		if filter.InStock {
+			// Products without variants do not track stock
+			db = db.Where("(NOT " + productHasVariants + " OR " +
+				"EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id " +
+				"AND product_variants.deleted_at IS NULL AND product_variants.stock > 0))")
+		}
+		if filter.HasVariants != nil {
+			hasVariants := productHasVariants
+			if *filter.HasVariants {
+				db = db.Where(hasVariants)
+			} else {
+				db = db.Where("NOT " + hasVariants)
+			}
+		}
+		if filter.Tag != "" {
+			db = db.Where("EXISTS (SELECT 1 FROM product_tags JOIN tags ON tags.id = product_tags.tag_id "+
+				"WHERE product_tags.product_id = products.id AND tags.name = ?)", filter.Tag)
+		}
+		return db
+	}
+}
+
+// ProductFacets counts the products matching a filter by each value a filter
+// chip can take. Each facet applies every criterion but its own, so choosing
+// a value shows how many products the other values of that facet would give.
+type ProductFacets struct {
+	Categories  []CategoryFacet
+	Tags        []TagFacet
+	InStock     int64
+	HasVariants int64
+	MinPrice    *int64
+	MaxPrice    *int64
+}
+
+// CategoryFacet counts the products directly in a category.
+type CategoryFacet struct {
+	CategoryID uint
+	Count      int64
+}
+
+type TagFacet struct {
+	Name  string
+	Count int64
+}
+
+// GetProductFacets computes the facets of the products matching filter.
+func (r *ProductRepository) GetProductFacets(ctx context.Context, filter *ProductFilter) (*ProductFacets, *common.Error) {
+	facets := &ProductFacets{}
+	products := func(f ProductFilter) *gorm.DB {
+		return r.db.WithContext(ctx).Model(&model.Product{}).Scopes(productFilterScope(&f))
+	}
+
+	byCategory := *filter
+	byCategory.CategoryID = nil
+	if err := products(byCategory).
+		Select("products.category_id, COUNT(*) AS count").
+		Group("products.category_id").
+		Order("count DESC, products.category_id").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+
+	byTag := *filter
+	byTag.Tag = ""
+	if err := products(byTag).
+		Joins("JOIN product_tags ON product_tags.product_id = products.id").
+		Joins("JOIN tags ON tags.id = product_tags.tag_id").
+		Select("tags.name, COUNT(*) AS count").
+		Group("tags.name").
+		Order("count DESC, tags.name").
+		Scan(&facets.Tags).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+
+	inStock := *filter
+	inStock.InStock = true
+	if err := products(inStock).Count(&facets.InStock).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+
+	hasVariants := *filter
+	withVariants := true
+	hasVariants.HasVariants = &withVariants
+	if err := products(hasVariants).Count(&facets.HasVariants).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+
+	byPrice := *filter
+	byPrice.MinPrice, byPrice.MaxPrice = nil, nil
+	var priceRange struct {
+		MinPrice *int64
+		MaxPrice *int64
+	}
+	if err := products(byPrice).
+		Select("MIN(products.price) AS min_price, MAX(products.price) AS max_price").
+		Scan(&priceRange).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	facets.MinPrice, facets.MaxPrice = priceRange.MinPrice, priceRange.MaxPrice
+
+	return facets, nil
+}
+
+// ReplaceProductTags sets the tags of a product to names, creating tags that
+// do not exist yet.
+func (r *ProductRepository) ReplaceProductTags(ctx context.Context, product *model.Product, names []string) *common.Error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tags := make([]model.Tag, 0, len(names))
+		for _, name := range names {
+			tag := model.Tag{Name: name}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("name = ?", name).First(&tag).Error; err != nil {
+				return err
+			}
+			tags = append(tags, tag)
+		}
+		return tx.Model(product).Association("Tags").Replace(tags)
+	})
+	if err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
+
+// UpdateProduct updates only product fields (not variants). When the slug
+// changes, the old one is kept as a redirect to the product.
 func (r *ProductRepository) UpdateProduct(ctx context.Context, product *model.Product) *common.Error {
 	m := &model.Product{
-		ID:          product.ID,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		CategoryID:  product.CategoryID,
+		ID:              product.ID,
+		Name:            product.Name,
+		Slug:            product.Slug,
+		Description:     product.Description,
+		Price:           product.Price,
+		Weight:          product.Weight,
+		CategoryID:      product.CategoryID,
+		MetaTitle:       product.MetaTitle,
+		MetaDescription: product.MetaDescription,
 	}
-	err := r.db.WithContext(ctx).Model(m).Updates(m).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous model.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "slug").
+			First(&previous, m.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(m).Updates(m).Error; err != nil {
+			return err
+		}
+		if previous.Slug == m.Slug {
+			return nil
+		}
+
+		// The product may be taking back a slug it had before
+		if err := tx.Where("slug = ?", m.Slug).Delete(&model.ProductSlugRedirect{}).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "slug"}},
+			DoUpdates: clause.AssignmentColumns([]string{"product_id", "created_at"}),
+		}).Create(&model.ProductSlugRedirect{Slug: previous.Slug, ProductID: m.ID}).Error
+	})
 	if err != nil {
 		return r.returnError(ctx, err)
 	}
 	return nil
 }
 
-func (r *ProductRepository) DeleteProduct(ctx context.Context, id uint) *common.Error {
-	err := r.db.WithContext(ctx).Delete(&model.Product{}, id).Error
+// ArchiveProduct soft-deletes a product with its variants and images. They
+// are stamped with the same time so RestoreProduct brings back exactly what
+// was archived with the product, not what had been deleted on its own before.
+// Tags are kept.
+func (r *ProductRepository) ArchiveProduct(ctx context.Context, id uint) *common.Error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			First(&product, id).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&model.ProductVariant{}).
+			Where("product_id = ?", id).
+			UpdateColumn("deleted_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.ProductImage{}).
+			Where("product_id = ?", id).
+			UpdateColumn("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&product).UpdateColumn("deleted_at", now).Error
+	})
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrNotFound(ctx, "Product", "not found")
+		}
 		return r.returnError(ctx, err)
 	}
 	return nil
 }
 
+// RestoreProduct brings an archived product back with the variants and images
+// archived along with it.
+func (r *ProductRepository) RestoreProduct(ctx context.Context, id uint) *common.Error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		if err := tx.Unscoped().
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "deleted_at").
+			Where("deleted_at IS NOT NULL").
+			First(&product, id).Error; err != nil {
+			return err
+		}
+
+		archivedAt := product.DeletedAt.Time
+		if err := tx.Unscoped().Model(&model.ProductVariant{}).
+			Where("product_id = ? AND deleted_at = ?", id, archivedAt).
+			UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.ProductImage{}).
+			Where("product_id = ? AND deleted_at = ?", id, archivedAt).
+			UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&product).UpdateColumn("deleted_at", nil).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrNotFound(ctx, "Archived product", "not found")
+		}
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
+
+// ListArchivedProducts lists archived products, most recently archived first,
+// with their main image.
+func (r *ProductRepository) ListArchivedProducts(ctx context.Context, offset, limit int) ([]*model.Product, int64, *common.Error) {
+	query := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.Product{}).
+		Where("deleted_at IS NOT NULL")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, r.returnError(ctx, err)
+	}
+
+	var products []*model.Product
+	if err := query.
+		Preload("ProductImages", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Where("is_main = ?", true)
+		}).
+		Preload("ProductImages.Image").
+		Order("deleted_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&products).Error; err != nil {
+		return nil, 0, r.returnError(ctx, err)
+	}
+
+	return products, total, nil
+}
+
+// ListHiddenProducts lists the products not on the storefront at now, such
+// as drafts and products scheduled for later, most recently updated first.
+func (r *ProductRepository) ListHiddenProducts(ctx context.Context, now time.Time, offset, limit int) ([]*model.Product, int64, *common.Error) {
+	query := r.db.WithContext(ctx).
+		Model(&model.Product{}).
+		Where("NOT ("+productVisible+")", productVisibleArgs(now)...)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, r.returnError(ctx, err)
+	}
+
+	var products []*model.Product
+	if err := query.
+		Preload("ProductImages", "is_main = ?", true).
+		Preload("ProductImages.Image").
+		Order("products.updated_at DESC, products.id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&products).Error; err != nil {
+		return nil, 0, r.returnError(ctx, err)
+	}
+
+	return products, total, nil
+}
+
+// UpdateProductPublication saves the status and schedule of a product,
+// clearing the times left unset.
+func (r *ProductRepository) UpdateProductPublication(ctx context.Context, product *model.Product) *common.Error {
+	err := r.db.WithContext(ctx).
+		Model(&model.Product{ID: product.ID}).
+		Select("status", "publish_at", "unpublish_at").
+		Updates(&model.Product{
+			Status:      product.Status,
+			PublishAt:   product.PublishAt,
+			UnpublishAt: product.UnpublishAt,
+		}).Error
+	if err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
+
+// ApplyPublicationSchedule publishes the scheduled products due by now, then
+// returns the published products whose unpublish time has come to draft. It
+// reports how many products went each way.
+func (r *ProductRepository) ApplyPublicationSchedule(ctx context.Context, now time.Time) (int64, int64, *common.Error) {
+	var published, unpublished int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Product{}).
+			Where("status = ? AND publish_at <= ?", model.ProductStatusScheduled, now).
+			Update("status", model.ProductStatusPublished)
+		if result.Error != nil {
+			return result.Error
+		}
+		published = result.RowsAffected
+
+		result = tx.Model(&model.Product{}).
+			Where("status = ? AND unpublish_at <= ?", model.ProductStatusPublished, now).
+			Update("status", model.ProductStatusDraft)
+		if result.Error != nil {
+			return result.Error
+		}
+		unpublished = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, 0, r.returnError(ctx, err)
+	}
+	return published, unpublished, nil
+}
+
 // === Product Variant Methods ===
 
 func (r *ProductRepository) GetProductVariantByID(ctx context.Context, id uint) (*model.ProductVariant, *common.Error) {
@@ -184,6 +709,7 @@ func (r *ProductRepository) UpdateProductVariant(ctx context.Context, variant *m
 		Name:      variant.Name,
 		Stock:     variant.Stock,
 		Price:     variant.Price,
+		Weight:    variant.Weight,
 	}
 	err := r.db.WithContext(ctx).Model(m).Updates(m).Error
 	if err != nil {
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"gorm.io/gorm"
+)
+
+type ShippingRuleRepository struct {
+	*baseRepository
+}
+
+func NewShippingRuleRepository(base *baseRepository) *ShippingRuleRepository {
+	return &ShippingRuleRepository{baseRepository: base}
+}
+
+func (r *ShippingRuleRepository) CreateShippingRule(ctx context.Context, rule *model.ShippingRule) *common.Error {
+	if err := r.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
+
+func (r *ShippingRuleRepository) GetShippingRuleByID(ctx context.Context, id uint) (*model.ShippingRule, *common.Error) {
+	var rule model.ShippingRule
+	if err := r.db.WithContext(ctx).First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound(ctx, "Shipping rule", "not found")
+		}
+		return nil, r.returnError(ctx, err)
+	}
+	return &rule, nil
+}
+
+func (r *ShippingRuleRepository) ListShippingRules(ctx context.Context) ([]model.ShippingRule, *common.Error) {
+	var rules []model.ShippingRule
+	if err := r.db.WithContext(ctx).
+		Order("province ASC, priority DESC, id ASC").
+		Find(&rules).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	return rules, nil
+}
+
+func (r *ShippingRuleRepository) ListActiveShippingRules(ctx context.Context) ([]model.ShippingRule, *common.Error) {
+	var rules []model.ShippingRule
+	if err := r.db.WithContext(ctx).
+		Where("active = ?", true).
+		Find(&rules).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	return rules, nil
+}
+
+func (r *ShippingRuleRepository) UpdateShippingRule(ctx context.Context, rule *model.ShippingRule) *common.Error {
+	if err := r.db.WithContext(ctx).Omit("created_at").Save(rule).Error; err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
+
+func (r *ShippingRuleRepository) DeleteShippingRule(ctx context.Context, id uint) *common.Error {
+	result := r.db.WithContext(ctx).Delete(&model.ShippingRule{}, id)
+	if result.Error != nil {
+		return r.returnError(ctx, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNotFound(ctx, "Shipping rule", "not found")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"gorm.io/gorm"
+)
+
+type VoucherRepository struct {
+	*baseRepository
+}
+
+func NewVoucherRepository(base *baseRepository) *VoucherRepository {
+	return &VoucherRepository{baseRepository: base}
+}
+
+func (r *VoucherRepository) CreateVoucher(ctx context.Context, voucher *model.Voucher) *common.Error {
+	if err := r.db.WithContext(ctx).Create(voucher).Error; err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
+
+func (r *VoucherRepository) GetVoucherByID(ctx context.Context, id uint) (*model.Voucher, *common.Error) {
+	var voucher model.Voucher
+	if err := r.db.WithContext(ctx).First(&voucher, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound(ctx, "Voucher", "not found")
+		}
+		return nil, r.returnError(ctx, err)
+	}
+	return &voucher, nil
+}
+
+// GetVoucherByCode looks a voucher up by code, ignoring case.
+func (r *VoucherRepository) GetVoucherByCode(ctx context.Context, code string) (*model.Voucher, *common.Error) {
+	var voucher model.Voucher
+	if err := r.db.WithContext(ctx).Where("UPPER(code) = UPPER(?)", code).Take(&voucher).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound(ctx, "Voucher", "not found")
+		}
+		return nil, r.returnError(ctx, err)
+	}
+	return &voucher, nil
+}
+
+func (r *VoucherRepository) IsCodeTaken(ctx context.Context, code string, excludeID uint) (bool, *common.Error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&model.Voucher{}).
+		Where("UPPER(code) = UPPER(?) AND id <> ?", code, excludeID).
+		Count(&count).Error; err != nil {
+		return false, r.returnError(ctx, err)
+	}
+	return count > 0, nil
+}
+
+func (r *VoucherRepository) ListVouchers(ctx context.Context, offset int, limit int) ([]*model.Voucher, int64, *common.Error) {
+	var vouchers []*model.Voucher
+	var total int64
+
+	if err := r.db.WithContext(ctx).Model(&model.Voucher{}).Count(&total).Error; err != nil {
+		return nil, 0, r.returnError(ctx, err)
+	}
+
+	if err := r.db.WithContext(ctx).
+		Offset(offset).
+		Limit(limit).
+		Order("created_at desc").
+		Find(&vouchers).Error; err != nil {
+		return nil, 0, r.returnError(ctx, err)
+	}
+
+	return vouchers, total, nil
+}
+
+// UpdateVoucher saves the voucher's settings. UsedCount is maintained by order
+// creation and is never overwritten here.
+func (r *VoucherRepository) UpdateVoucher(ctx context.Context, voucher *model.Voucher) *common.Error {
+	if err := r.db.WithContext(ctx).Omit("used_count", "created_at").Save(voucher).Error; err != nil {
+		return r.returnError(ctx, err)
+	}
+	return nil
+}
+
+// DeleteVoucher removes a voucher that has never been used. Used vouchers stay
+// referenced by their orders and can only be deactivated.
+func (r *VoucherRepository) DeleteVoucher(ctx context.Context, id uint) *common.Error {
+	var usages int64
+	if err := r.db.WithContext(ctx).Model(&model.VoucherUsage{}).Where("voucher_id = ?", id).Count(&usages).Error; err != nil {
+		return r.returnError(ctx, err)
+	}
+	if usages > 0 {
+		return common.ErrConflict(ctx, "Voucher", "has been used and can only be deactivated")
+	}
+
+	result := r.db.WithContext(ctx).Delete(&model.Voucher{}, id)
+	if result.Error != nil {
+		return r.returnError(ctx, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNotFound(ctx, "Voucher", "not found")
+	}
+	return nil
+}
+
+func (r *VoucherRepository) ListVoucherUsages(ctx context.Context, voucherID uint) ([]*model.VoucherUsage, *common.Error) {
+	var usages []*model.VoucherUsage
+	if err := r.db.WithContext(ctx).
+		Where("voucher_id = ?", voucherID).
+		Order("created_at DESC, id DESC").
+		Find(&usages).Error; err != nil {
+		return nil, r.returnError(ctx, err)
+	}
+	return usages, nil
+}
@@ -58,6 +58,25 @@ func (b *baseController) GetStringParams(c *gin.Context, key string) (string, *c
 	return param, nil
 }
 
+// maxClaimedActorLen bounds the X-Actor value kept in the audit trail.
+const maxClaimedActorLen = 64
+
+// GetActor identifies who is calling an admin endpoint for the audit trail.
+// Admin requests carry no authenticated identity, so the actor is the client
+// IP; a name given in the X-Actor header is kept alongside it, labelled as
+// unverified since any client can set it.
+func (b *baseController) GetActor(c *gin.Context) string {
+	actor := c.ClientIP()
+	claimed := strings.TrimSpace(c.GetHeader("X-Actor"))
+	if claimed == "" {
+		return actor
+	}
+	if runes := []rune(claimed); len(runes) > maxClaimedActorLen {
+		claimed = string(runes[:maxClaimedActorLen])
+	}
+	return fmt.Sprintf("%s (unverified: %s)", actor, claimed)
+}
+
 func (b *baseController) GetFile(c *gin.Context, key string) (*multipart.FileHeader, *common.Error) {
 	file, err := c.FormFile(key)
 	if err != nil {
@@ -96,8 +115,10 @@ func (b *baseController) GetPaginationParams(ctx *gin.Context) (*dto.PaginationR
 	}
 
 	return &dto.PaginationRequest{
-		Page: page,
-		Size: size,
+		Page:   page,
+		Size:   size,
+		SortBy: ctx.Query("sort_by"),
+		Order:  ctx.Query("order"),
 	}, nil
 }
 
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"net/http"
+
+	httpCommon "github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/middleware"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CartController struct {
+	*baseController
+	cartService *services.CartService
+	authService *services.AuthService
+}
+
+func NewCartController(baseController *baseController, cartService *services.CartService, authService *services.AuthService) *CartController {
+	return &CartController{
+		baseController: baseController,
+		cartService:    cartService,
+		authService:    authService,
+	}
+}
+
+func (c *CartController) GetCart(ctx *gin.Context) {
+	cart, err := c.cartService.GetCart(ctx.Request.Context())
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	c.Success(ctx, cart)
+}
+
+func (c *CartController) AddItem(ctx *gin.Context) {
+	var req dto.AddCartItemRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	cart, err := c.cartService.AddItem(ctx.Request.Context(), &req)
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	c.Success(ctx, cart)
+}
+
+func (c *CartController) UpdateItem(ctx *gin.Context) {
+	id, err := c.GetUintParam(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	var req dto.UpdateCartItemRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	cart, errSvc := c.cartService.UpdateItem(ctx.Request.Context(), id, &req)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	c.Success(ctx, cart)
+}
+
+func (c *CartController) RemoveItem(ctx *gin.Context) {
+	id, err := c.GetUintParam(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	cart, errSvc := c.cartService.RemoveItem(ctx.Request.Context(), id)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	c.Success(ctx, cart)
+}
+
+func (c *CartController) ClearCart(ctx *gin.Context) {
+	if err := c.cartService.ClearCart(ctx.Request.Context()); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	c.Success(ctx, map[string]string{"message": "success"})
+}
+
+func (c *CartController) Checkout(ctx *gin.Context) {
+	var req dto.CheckoutCartRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	res, err := c.cartService.Checkout(ctx.Request.Context(), &req)
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, httpCommon.NewSuccessResponse(res))
+}
+
+func (c *CartController) RegisterRoutes(r *gin.RouterGroup) {
+	cart := r.Group("/me/cart", middleware.ZaloUserAuth(c.authService))
+	{
+		cart.GET("", c.GetCart)
+		cart.DELETE("", c.ClearCart)
+		cart.POST("/items", c.AddItem)
+		cart.PUT("/items/:id", c.UpdateItem)
+		cart.DELETE("/items/:id", c.RemoveItem)
+		cart.POST("/checkout", c.Checkout)
+	}
+}
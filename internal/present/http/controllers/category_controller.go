@@ -136,7 +136,13 @@ func (c *CategoryController) GetProductsByCategory(ctx *gin.Context) {
 		return
 	}
 
-	res, err := c.categoryService.GetProductsByCategory(ctx.Request.Context(), id, paginationReq.Page, paginationReq.Size)
+	var query dto.ProductListQuery
+	if err := c.BindAndValidateRequest(ctx, &query); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	res, err := c.categoryService.GetProductsByCategory(ctx.Request.Context(), id, paginationReq, &query)
 	if err != nil {
 		c.ErrorData(ctx, err)
 		return
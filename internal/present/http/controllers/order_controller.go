@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
 	httpCommon "github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/common"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/middleware"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
@@ -12,12 +16,14 @@ import (
 type OrderController struct {
 	*baseController
 	orderService *services.OrderService
+	authService  *services.AuthService
 }
 
-func NewOrderController(baseController *baseController, orderService *services.OrderService) *OrderController {
+func NewOrderController(baseController *baseController, orderService *services.OrderService, authService *services.AuthService) *OrderController {
 	return &OrderController{
 		baseController: baseController,
 		orderService:   orderService,
+		authService:    authService,
 	}
 }
 
@@ -44,7 +50,13 @@ func (c *OrderController) ListOrders(ctx *gin.Context) {
 		return
 	}
 
-	orders, total, errSvc := c.orderService.ListOrders(ctx.Request.Context(), pagination.Page, pagination.Size)
+	var query dto.OrderListQuery
+	if err := c.BindAndValidateRequest(ctx, &query); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	orders, total, errSvc := c.orderService.ListOrders(ctx.Request.Context(), pagination, &query)
 	if errSvc != nil {
 		c.ErrorData(ctx, errSvc)
 		return
@@ -71,6 +83,43 @@ func (c *OrderController) GetOrder(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(order))
 }
 
+func (c *OrderController) GetOrderTimeline(ctx *gin.Context) {
+	id, err := c.GetStringParams(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	events, errSvc := c.orderService.GetOrderTimeline(ctx.Request.Context(), id)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	responses := make([]*dto.OrderEventResponse, 0, len(events))
+	for _, event := range events {
+		responses = append(responses, dto.NewOrderEventResponse(event))
+	}
+
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(responses))
+}
+
+func (c *OrderController) GetOrderVietQR(ctx *gin.Context) {
+	id, err := c.GetStringParams(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	qr, errSvc := c.orderService.GetOrderVietQR(ctx.Request.Context(), id)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(qr))
+}
+
 func (c *OrderController) UpdateOrder(ctx *gin.Context) {
 	var req dto.UpdateOrderRequest
 	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
@@ -78,7 +127,7 @@ func (c *OrderController) UpdateOrder(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.orderService.UpdateOrder(ctx.Request.Context(), &req); err != nil {
+	if err := c.orderService.UpdateOrder(ctx.Request.Context(), &req, c.GetActor(ctx)); err != nil {
 		c.ErrorData(ctx, err)
 		return
 	}
@@ -86,12 +135,188 @@ func (c *OrderController) UpdateOrder(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(nil))
 }
 
+func (c *OrderController) ListMyOrders(ctx *gin.Context) {
+	pagination, err := c.GetPaginationParams(ctx)
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	var status *model.OrderStatus
+	if raw := ctx.Query("status"); raw != "" {
+		parsed := model.OrderStatus(raw)
+		if !parsed.IsValid() {
+			c.ErrorData(ctx, common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("unknown order status %q", raw)).SetSource(common.CurrentService))
+			return
+		}
+		status = &parsed
+	}
+
+	orders, total, errSvc := c.orderService.ListCustomerOrders(ctx.Request.Context(), pagination.Page, pagination.Size, status)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewPaginationResponse(orders, total, *pagination))
+}
+
+func (c *OrderController) GetMyOrder(ctx *gin.Context) {
+	id, err := c.GetStringParams(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	order, errSvc := c.orderService.GetCustomerOrder(ctx.Request.Context(), id)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(order))
+}
+
+func (c *OrderController) GetMyOrderVietQR(ctx *gin.Context) {
+	id, err := c.GetStringParams(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	qr, errSvc := c.orderService.GetCustomerOrderVietQR(ctx.Request.Context(), id)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(qr))
+}
+
+func (c *OrderController) CancelOrder(ctx *gin.Context) {
+	id, err := c.GetStringParams(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	var req dto.CancelOrderRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	order, errSvc := c.orderService.CancelOrder(ctx.Request.Context(), id, req.Reason, c.GetActor(ctx))
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(order))
+}
+
+func (c *OrderController) CancelMyOrder(ctx *gin.Context) {
+	id, err := c.GetStringParams(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	var req dto.CancelOrderRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	order, errSvc := c.orderService.CancelCustomerOrder(ctx.Request.Context(), id, req.Reason)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(order))
+}
+
+func (c *OrderController) ConfirmCodDelivery(ctx *gin.Context) {
+	id, err := c.GetStringParams(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	var req dto.ConfirmCodRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	order, errSvc := c.orderService.ConfirmCodDelivery(ctx.Request.Context(), id, &req, c.GetActor(ctx))
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(order))
+}
+
+func (c *OrderController) RefundOrder(ctx *gin.Context) {
+	id, err := c.GetStringParams(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	var req dto.CreateRefundRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	order, refund, errSvc := c.orderService.RefundOrder(ctx.Request.Context(), id, &req, c.GetActor(ctx))
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, httpCommon.NewSuccessResponse(&dto.RefundResponse{Order: order, Refund: refund}))
+}
+
+func (c *OrderController) ListRefunds(ctx *gin.Context) {
+	id, err := c.GetStringParams(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	refunds, errSvc := c.orderService.ListRefunds(ctx.Request.Context(), id)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(refunds))
+}
+
 func (c *OrderController) RegisterRoutes(r *gin.RouterGroup) {
 	orders := r.Group("/orders")
 	{
-		orders.POST("", c.CreateOrder)
+		orders.POST("", middleware.ZaloUserOptional(c.authService), c.CreateOrder)
 		orders.GET("", c.ListOrders)
 		orders.GET("/:id", c.GetOrder)
+		orders.GET("/:id/timeline", c.GetOrderTimeline)
+		orders.GET("/:id/vietqr", c.GetOrderVietQR)
+		orders.POST("/:id/cancel", c.CancelOrder)
+		orders.POST("/:id/cod", c.ConfirmCodDelivery)
+		orders.GET("/:id/refunds", c.ListRefunds)
+		orders.POST("/:id/refunds", c.RefundOrder)
 		orders.PUT("/", c.UpdateOrder)
 	}
+
+	myOrders := r.Group("/me/orders", middleware.ZaloUserAuth(c.authService))
+	{
+		myOrders.GET("", c.ListMyOrders)
+		myOrders.GET("/:id", c.GetMyOrder)
+		myOrders.GET("/:id/vietqr", c.GetMyOrderVietQR)
+		myOrders.POST("/:id/cancel", c.CancelMyOrder)
+	}
 }
@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"net/http"
+	"net/url"
+	"path"
 
 	httpCommon "github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/common"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
@@ -14,8 +16,9 @@ type ProductController struct {
 	productService *services.ProductService
 }
 
-func NewProductController(productService *services.ProductService) *ProductController {
+func NewProductController(baseController *baseController, productService *services.ProductService) *ProductController {
 	return &ProductController{
+		baseController: baseController,
 		productService: productService,
 	}
 }
@@ -24,10 +27,18 @@ func (pc *ProductController) RegisterRoutes(r *gin.RouterGroup) {
 	products := r.Group("/products")
 	{
 		products.POST("", pc.CreateProduct)
+		products.GET("/search", pc.SearchProducts)
+		products.GET("/slug/:slug", pc.GetProductBySlug)
 		products.GET("/:id", pc.GetProductByID)
 		products.GET("", pc.GetAllProduct)
 		products.PUT("", pc.UpdateProduct)
-		products.DELETE("/:id", pc.DeleteProduct)
+		products.DELETE("/:id", pc.ArchiveProduct)
+		products.GET("/trash", pc.ListArchivedProducts)
+		products.GET("/drafts", pc.ListHiddenProducts)
+		products.GET("/:id/preview", pc.PreviewProduct)
+		products.PUT("/:id/publication", pc.UpdateProductPublication)
+		products.POST("/:id/archive", pc.ArchiveProduct)
+		products.POST("/:id/restore", pc.RestoreProduct)
 
 		products.POST("/variants", pc.AddProductVariant)
 		products.PUT("/variants", pc.UpdateProductVariant)
@@ -69,28 +80,132 @@ func (pc *ProductController) GetProductByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(dto.NewProductResponse(product)))
 }
 
-func (pc *ProductController) GetAllProduct(ctx *gin.Context) {
+// PreviewProduct shows a product as the storefront would, even while it is a
+// draft or scheduled for later.
+func (pc *ProductController) PreviewProduct(ctx *gin.Context) {
+	id, err := pc.GetUintParam(ctx, "id")
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	product, err := pc.productService.PreviewProduct(ctx.Request.Context(), id)
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(dto.NewProductResponse(product)))
+}
+
+// ListHiddenProducts lists the products not on the storefront: drafts,
+// products scheduled for later and products past their unpublish time.
+func (pc *ProductController) ListHiddenProducts(ctx *gin.Context) {
 	pagination, err := pc.GetPaginationParams(ctx)
 	if err != nil {
 		pc.ErrorData(ctx, err)
 		return
 	}
 
-	products, total, err := pc.productService.ListProducts(ctx.Request.Context(), pagination.Page, pagination.Size)
+	products, total, err := pc.productService.ListHiddenProducts(ctx.Request.Context(), pagination)
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	responses := make([]dto.ProductResponse, 0, len(products))
+	for _, p := range products {
+		responses = append(responses, *dto.NewProductResponse(p))
+	}
+	ctx.JSON(http.StatusOK, dto.NewPaginationResponse(responses, total, *pagination))
+}
+
+func (pc *ProductController) UpdateProductPublication(ctx *gin.Context) {
+	id, err := pc.GetUintParam(ctx, "id")
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	req := dto.UpdateProductPublicationRequest{}
+	if err := pc.BindAndValidateRequest(ctx, &req); err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	product, err := pc.productService.UpdateProductPublication(ctx.Request.Context(), id, &req)
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(dto.NewProductResponse(product)))
+}
+
+// GetProductBySlug answers a slug the product had before with a permanent
+// redirect to its current slug.
+func (pc *ProductController) GetProductBySlug(ctx *gin.Context) {
+	slug, err := pc.GetStringParams(ctx, "slug")
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	product, current, err := pc.productService.GetProductBySlug(ctx.Request.Context(), slug)
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+	if product == nil {
+		ctx.Redirect(http.StatusMovedPermanently, path.Join(path.Dir(ctx.Request.URL.Path), url.PathEscape(current)))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(dto.NewProductResponse(product)))
+}
+
+func (pc *ProductController) GetAllProduct(ctx *gin.Context) {
+	pagination, err := pc.GetPaginationParams(ctx)
 	if err != nil {
 		pc.ErrorData(ctx, err)
 		return
 	}
 
-	productsResponse := make([]*dto.ProductResponse, 0, len(products))
-	for _, product := range products {
-		productsResponse = append(productsResponse, dto.NewProductResponse(product))
+	var query dto.ProductListQuery
+	if err := pc.BindAndValidateRequest(ctx, &query); err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	response, err := pc.productService.ListProducts(ctx.Request.Context(), pagination, &query)
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
 	}
 
-	response := dto.NewPaginationResponse(productsResponse, total, *pagination)
 	ctx.JSON(200, response)
 }
 
+func (pc *ProductController) SearchProducts(ctx *gin.Context) {
+	pagination, err := pc.GetPaginationParams(ctx)
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	var query dto.ProductSearchQuery
+	if err := pc.BindAndValidateRequest(ctx, &query); err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	response, err := pc.productService.SearchProducts(ctx.Request.Context(), pagination, &query)
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
 func (pc *ProductController) UpdateProduct(ctx *gin.Context) {
 	request := dto.UpdateProductRequest{}
 	if err := pc.BindAndValidateRequest(ctx, &request); err != nil {
@@ -105,18 +220,55 @@ func (pc *ProductController) UpdateProduct(ctx *gin.Context) {
 	ctx.JSON(200, gin.H{"message": "Product updated successfully"})
 }
 
-func (pc *ProductController) DeleteProduct(ctx *gin.Context) {
+// ArchiveProduct moves a product to the trash; deleting a product archives it
+// so a mistaken delete can be restored.
+func (pc *ProductController) ArchiveProduct(ctx *gin.Context) {
 	id, err := pc.GetUintParam(ctx, "id")
 	if err != nil {
 		pc.ErrorData(ctx, err)
 		return
 	}
 
-	if err := pc.productService.DeleteProduct(ctx.Request.Context(), id); err != nil {
+	if err := pc.productService.ArchiveProduct(ctx.Request.Context(), id); err != nil {
 		pc.ErrorData(ctx, err)
 		return
 	}
-	ctx.JSON(200, gin.H{"message": "Product deleted successfully"})
+	ctx.JSON(200, gin.H{"message": "Product archived successfully"})
+}
+
+func (pc *ProductController) RestoreProduct(ctx *gin.Context) {
+	id, err := pc.GetUintParam(ctx, "id")
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	product, err := pc.productService.RestoreProduct(ctx.Request.Context(), id)
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, httpCommon.NewSuccessResponse(dto.NewProductResponse(product)))
+}
+
+func (pc *ProductController) ListArchivedProducts(ctx *gin.Context) {
+	pagination, err := pc.GetPaginationParams(ctx)
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	products, total, err := pc.productService.ListArchivedProducts(ctx.Request.Context(), pagination)
+	if err != nil {
+		pc.ErrorData(ctx, err)
+		return
+	}
+
+	responses := make([]dto.ProductResponse, 0, len(products))
+	for _, p := range products {
+		responses = append(responses, *dto.NewProductResponse(p))
+	}
+	ctx.JSON(http.StatusOK, dto.NewPaginationResponse(responses, total, *pagination))
 }
 
 func (pc *ProductController) AddProductVariant(ctx *gin.Context) {
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationController struct {
+	*baseController
+	reconciliationService *services.ReconciliationService
+}
+
+func NewReconciliationController(baseController *baseController, reconciliationService *services.ReconciliationService) *ReconciliationController {
+	return &ReconciliationController{
+		baseController:        baseController,
+		reconciliationService: reconciliationService,
+	}
+}
+
+func (c *ReconciliationController) GetReport(ctx *gin.Context) {
+	var query dto.ReconciliationQuery
+	if err := c.BindAndValidateRequest(ctx, &query); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	report, err := c.reconciliationService.Reconcile(ctx.Request.Context(), &query)
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	if query.Format != "csv" {
+		c.Success(ctx, report)
+		return
+	}
+
+	data, errCSV := report.CSV()
+	if errCSV != nil {
+		c.ErrorData(ctx, common.ErrSystemError(ctx.Request.Context(), errCSV.Error()))
+		return
+	}
+	filename := fmt.Sprintf("reconciliation_%s_%s.csv", query.From, query.To)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+func (c *ReconciliationController) RegisterRoutes(r *gin.RouterGroup) {
+	reconciliation := r.Group("/reconciliation")
+	{
+		reconciliation.GET("", c.GetReport)
+	}
+}
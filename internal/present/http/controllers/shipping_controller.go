@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"net/http"
+
+	httpCommon "github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ShippingController struct {
+	*baseController
+	shippingService *services.ShippingService
+	orderService    *services.OrderService
+}
+
+func NewShippingController(baseController *baseController, shippingService *services.ShippingService, orderService *services.OrderService) *ShippingController {
+	return &ShippingController{
+		baseController:  baseController,
+		shippingService: shippingService,
+		orderService:    orderService,
+	}
+}
+
+func (c *ShippingController) QuoteShipping(ctx *gin.Context) {
+	var req dto.ShippingQuoteRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	quote, err := c.orderService.QuoteShipping(ctx.Request.Context(), &req)
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	c.Success(ctx, quote)
+}
+
+func (c *ShippingController) CreateShippingRule(ctx *gin.Context) {
+	var req dto.ShippingRuleRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	rule, err := c.shippingService.CreateShippingRule(ctx.Request.Context(), &req)
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, httpCommon.NewSuccessResponse(rule))
+}
+
+func (c *ShippingController) ListShippingRules(ctx *gin.Context) {
+	rules, err := c.shippingService.ListShippingRules(ctx.Request.Context())
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	c.Success(ctx, rules)
+}
+
+func (c *ShippingController) GetShippingRule(ctx *gin.Context) {
+	id, err := c.GetUintParam(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	rule, errSvc := c.shippingService.GetShippingRule(ctx.Request.Context(), id)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	c.Success(ctx, rule)
+}
+
+func (c *ShippingController) UpdateShippingRule(ctx *gin.Context) {
+	id, err := c.GetUintParam(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	var req dto.ShippingRuleRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	rule, errSvc := c.shippingService.UpdateShippingRule(ctx.Request.Context(), id, &req)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	c.Success(ctx, rule)
+}
+
+func (c *ShippingController) DeleteShippingRule(ctx *gin.Context) {
+	id, err := c.GetUintParam(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	if err := c.shippingService.DeleteShippingRule(ctx.Request.Context(), id); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	c.Success(ctx, map[string]string{"message": "success"})
+}
+
+func (c *ShippingController) RegisterRoutes(r *gin.RouterGroup) {
+	shipping := r.Group("/shipping")
+	{
+		shipping.POST("/quote", c.QuoteShipping)
+		shipping.POST("/rules", c.CreateShippingRule)
+		shipping.GET("/rules", c.ListShippingRules)
+		shipping.GET("/rules/:id", c.GetShippingRule)
+		shipping.PUT("/rules/:id", c.UpdateShippingRule)
+		shipping.DELETE("/rules/:id", c.DeleteShippingRule)
+	}
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"net/http"
+
+	httpCommon "github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type VoucherController struct {
+	*baseController
+	voucherService *services.VoucherService
+}
+
+func NewVoucherController(baseController *baseController, voucherService *services.VoucherService) *VoucherController {
+	return &VoucherController{
+		baseController: baseController,
+		voucherService: voucherService,
+	}
+}
+
+func (c *VoucherController) CreateVoucher(ctx *gin.Context) {
+	var req dto.CreateVoucherRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	voucher, err := c.voucherService.CreateVoucher(ctx.Request.Context(), &req)
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, httpCommon.NewSuccessResponse(voucher))
+}
+
+func (c *VoucherController) ListVouchers(ctx *gin.Context) {
+	pagination, err := c.GetPaginationParams(ctx)
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	vouchers, total, errSvc := c.voucherService.ListVouchers(ctx.Request.Context(), pagination.Page, pagination.Size)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewPaginationResponse(vouchers, total, *pagination))
+}
+
+func (c *VoucherController) GetVoucher(ctx *gin.Context) {
+	id, err := c.GetUintParam(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	voucher, errSvc := c.voucherService.GetVoucher(ctx.Request.Context(), id)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	c.Success(ctx, voucher)
+}
+
+func (c *VoucherController) UpdateVoucher(ctx *gin.Context) {
+	id, err := c.GetUintParam(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	var req dto.UpdateVoucherRequest
+	if err := c.BindAndValidateRequest(ctx, &req); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	voucher, errSvc := c.voucherService.UpdateVoucher(ctx.Request.Context(), id, &req)
+	if errSvc != nil {
+		c.ErrorData(ctx, errSvc)
+		return
+	}
+
+	c.Success(ctx, voucher)
+}
+
+func (c *VoucherController) DeleteVoucher(ctx *gin.Context) {
+	id, err := c.GetUintParam(ctx, "id")
+	if err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	if err := c.voucherService.DeleteVoucher(ctx.Request.Context(), id); err != nil {
+		c.ErrorData(ctx, err)
+		return
+	}
+
+	c.Success(ctx, map[string]string{"message": "success"})
+}
+
+func (c *VoucherController) RegisterRoutes(r *gin.RouterGroup) {
+	vouchers := r.Group("/vouchers")
+	{
+		vouchers.POST("", c.CreateVoucher)
+		vouchers.GET("", c.ListVouchers)
+		vouchers.GET("/:id", c.GetVoucher)
+		vouchers.PUT("/:id", c.UpdateVoucher)
+		vouchers.DELETE("/:id", c.DeleteVoucher)
+	}
+}
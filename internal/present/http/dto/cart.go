@@ -0,0 +1,49 @@
+package dto
+
+type AddCartItemRequest struct {
+	ProductID uint  `json:"product_id" validate:"required"`
+	VariantID *uint `json:"variant_id"`
+	Quantity  int   `json:"quantity" validate:"required,gt=0"`
+}
+
+type UpdateCartItemRequest struct {
+	// Quantity 0 removes the item.
+	Quantity int `json:"quantity" validate:"gte=0"`
+}
+
+type CheckoutCartRequest struct {
+	CustomerInfo CustomerInfoRequest `json:"customer_info" validate:"required"`
+	Payment      PaymentRequest      `json:"payment" validate:"required"`
+	VoucherCode  string              `json:"voucher_code" validate:"max=50"`
+}
+
+// Reasons a cart item cannot be checked out as it is.
+const (
+	CartItemIssueProductUnavailable = "product_unavailable"
+	CartItemIssueVariantRequired    = "variant_required"
+	CartItemIssueVariantUnavailable = "variant_unavailable"
+	CartItemIssueOutOfStock         = "out_of_stock"
+	CartItemIssueInsufficientStock  = "insufficient_stock"
+)
+
+type CartItemResponse struct {
+	ID          uint   `json:"id"`
+	ProductID   uint   `json:"product_id"`
+	VariantID   *uint  `json:"variant_id,omitempty"`
+	Name        string `json:"name"`
+	VariantName string `json:"variant_name,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	UnitPrice   int64  `json:"unit_price"`
+	Quantity    int    `json:"quantity"`
+	LineTotal   int64  `json:"line_total"`
+	Stock       *int64 `json:"stock,omitempty"`
+	Issue       string `json:"issue,omitempty"`
+}
+
+type CartResponse struct {
+	ID            uint               `json:"id"`
+	Items         []CartItemResponse `json:"items"`
+	TotalQuantity int                `json:"total_quantity"`
+	Subtotal      int64              `json:"subtotal"`
+	HasIssues     bool               `json:"has_issues"`
+}
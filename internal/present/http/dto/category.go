@@ -5,15 +5,17 @@ import (
 )
 
 type CreateCategoryRequest struct {
-	Name    string `json:"name" validate:"required,min=1,max=255"`
-	Slug    string `json:"slug" validate:"required,min=1,max=255"`
-	ImageID *uint  `json:"image_id"`
+	Name     string `json:"name" validate:"required,min=1,max=255"`
+	Slug     string `json:"slug" validate:"required,min=1,max=255"`
+	ImageID  *uint  `json:"image_id"`
+	ParentID *uint  `json:"parent_id" validate:"omitempty,gt=0"`
 }
 
 func (r *CreateCategoryRequest) ToModel() *model.Category {
 	return &model.Category{
-		Name: r.Name,
-		Slug: r.Slug,
+		Name:     r.Name,
+		Slug:     r.Slug,
+		ParentID: r.ParentID,
 		// ImageID will be handled in service if needed, or domain struct updated to hold ImageID
 	}
 }
@@ -23,12 +25,15 @@ type UpdateCategoryRequest struct {
 	Name    *string `json:"name,omitempty"`
 	Slug    *string `json:"slug,omitempty"`
 	ImageID *uint   `json:"image_id,omitempty"`
+	// ParentID moves the category under another; 0 makes it top level.
+	ParentID *uint `json:"parent_id,omitempty"`
 }
 
 type CategoryResponse struct {
 	ID        uint           `json:"id"`
 	Name      string         `json:"name"`
 	Slug      string         `json:"slug"`
+	ParentID  *uint          `json:"parent_id,omitempty"`
 	Image     *ImageResponse `json:"image,omitempty"`
 	CreatedAt string         `json:"created_at"`
 	UpdatedAt string         `json:"updated_at"`
@@ -44,6 +49,7 @@ func NewCategoryResponse(category *model.Category) *CategoryResponse {
 		ID:        category.ID,
 		Name:      category.Name,
 		Slug:      category.Slug,
+		ParentID:  category.ParentID,
 		Image:     imageResp,
 		CreatedAt: category.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt: category.UpdatedAt.Format("2006-01-02 15:04:05"),
@@ -8,14 +8,16 @@ import (
 )
 
 type CustomerInfoRequest struct {
-	Name    string `json:"name" validate:"required"`
-	Phone   string `json:"phone" validate:"required"`
-	Address string `json:"address" validate:"required"`
+	Name     string `json:"name" validate:"required"`
+	Phone    string `json:"phone" validate:"required"`
+	Address  string `json:"address" validate:"required"`
+	Province string `json:"province" validate:"max=100"`
 }
 
 type OrderItemRequest struct {
-	ProductID uint `json:"product_id" validate:"required"`
-	Quantity  int  `json:"quantity" validate:"required,gt=0"`
+	ProductID uint  `json:"product_id" validate:"required"`
+	VariantID *uint `json:"variant_id"`
+	Quantity  int   `json:"quantity" validate:"required,gt=0"`
 }
 
 type PaymentRequest struct {
@@ -26,6 +28,21 @@ type CreateOrderRequest struct {
 	CustomerInfo CustomerInfoRequest `json:"customer_info" validate:"required"`
 	Items        []OrderItemRequest  `json:"items" validate:"required,dive"`
 	Payment      PaymentRequest      `json:"payment" validate:"required"`
+	VoucherCode  string              `json:"voucher_code" validate:"max=50"`
+}
+
+// OrderListQuery filters the admin order list. Status takes a comma-separated
+// list; dates take RFC 3339 or YYYY-MM-DD, and a bare created_to date covers
+// that whole day. Q searches the order ID, customer phone and name, the
+// transaction ID and the Zalo order ID.
+type OrderListQuery struct {
+	Status        string `form:"status"`
+	PaymentMethod string `form:"payment_method" validate:"max=50"`
+	CreatedFrom   string `form:"created_from"`
+	CreatedTo     string `form:"created_to"`
+	MinTotal      *int64 `form:"min_total" validate:"omitempty,gte=0"`
+	MaxTotal      *int64 `form:"max_total" validate:"omitempty,gte=0"`
+	Q             string `form:"q" validate:"max=100"`
 }
 
 type OrderResponse struct {
@@ -49,6 +66,7 @@ type CreateOrderResponse struct {
 	*model.Order
 	ZaloParams *ZaloOrderParams `json:"zalo_params"`
 	MAC        string           `json:"mac"` // Deprecated but keeping for now
+	VietQR     *VietQRResponse  `json:"vietqr,omitempty"`
 }
 
 type OrderSubmitRequest struct {
@@ -56,9 +74,61 @@ type OrderSubmitRequest struct {
 	ZaloOrderID string `json:"zalo_order_id" validate:"required"`
 }
 
+type CancelOrderRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// Outcomes of a COD delivery.
+const (
+	CodResultCollected = "collected"
+	CodResultFailed    = "failed"
+)
+
+type ConfirmCodRequest struct {
+	Result    string `json:"result" validate:"required,oneof=collected failed"`
+	Note      string `json:"note" validate:"max=500"`
+	Reference string `json:"reference" validate:"max=255"`
+}
+
+type CreateRefundRequest struct {
+	// Amount defaults to everything not yet refunded.
+	Amount    *decimal.Decimal `json:"amount"`
+	Reason    string           `json:"reason" validate:"required,max=500"`
+	Reference string           `json:"reference" validate:"max=255"`
+}
+
+type RefundResponse struct {
+	Order  *model.Order  `json:"order"`
+	Refund *model.Refund `json:"refund"`
+}
+
 type UpdateOrderRequest struct {
 	OrderID       string             `json:"order_id" validate:"required"`
 	ZaloOrderID   *string            `json:"zalo_order_id"`
 	Status        *model.OrderStatus `json:"status"`
 	TransactionID *string            `json:"transaction_id"`
 }
+
+type OrderEventResponse struct {
+	ID         uint      `json:"id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	Source     string    `json:"source"`
+	Actor      string    `json:"actor,omitempty"`
+	Reference  string    `json:"reference,omitempty"`
+	Note       string    `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewOrderEventResponse(event *model.OrderEvent) *OrderEventResponse {
+	return &OrderEventResponse{
+		ID:         event.ID,
+		FromStatus: string(event.FromStatus),
+		ToStatus:   string(event.ToStatus),
+		Source:     string(event.Source),
+		Actor:      event.Actor,
+		Reference:  event.Reference,
+		Note:       event.Note,
+		CreatedAt:  event.CreatedAt,
+	}
+}
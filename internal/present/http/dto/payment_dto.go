@@ -14,3 +14,15 @@ type WebhookReceiverRequest struct {
 	ReferenceCode   string  `json:"referenceCode"`
 	Description     string  `json:"description"`
 }
+
+// VietQRResponse is a QR code the customer scans in their bank app to transfer
+// the amount due with the order ID as the transfer content.
+type VietQRResponse struct {
+	BankBIN       string `json:"bank_bin"`
+	AccountNumber string `json:"account_number"`
+	AccountName   string `json:"account_name,omitempty"`
+	Amount        int64  `json:"amount"`
+	Content       string `json:"content"`
+	Payload       string `json:"payload"`
+	Image         string `json:"image"` // PNG as a data URL
+}
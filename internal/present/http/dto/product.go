@@ -1,20 +1,24 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
 )
 
 type CreateProductVariantRequest struct {
-	Name  string `json:"name" binding:"required,min=1,max=255"`
-	Price int64  `json:"price" binding:"required,gt=0"`
-	Stock int64  `json:"stock"`
+	Name   string `json:"name" binding:"required,min=1,max=255"`
+	Price  int64  `json:"price" binding:"required,gt=0"`
+	Stock  int64  `json:"stock"`
+	Weight int64  `json:"weight" binding:"gte=0"`
 }
 
 type UpdateProductVariantRequest struct {
-	ID    uint    `json:"id,omitempty"`
-	Name  *string `json:"name,omitempty"`
-	Price *int64  `json:"price,omitempty"`
-	Stock *int64  `json:"stock,omitempty"`
+	ID     uint    `json:"id,omitempty"`
+	Name   *string `json:"name,omitempty"`
+	Price  *int64  `json:"price,omitempty"`
+	Stock  *int64  `json:"stock,omitempty"`
+	Weight *int64  `json:"weight,omitempty"`
 }
 
 type AddProductVariantRequest struct {
@@ -22,6 +26,7 @@ type AddProductVariantRequest struct {
 	Name      string `json:"name" binding:"required,min=1,max=255"`
 	Price     int64  `json:"price" binding:"required,gt=0"`
 	Stock     int64  `json:"stock"`
+	Weight    int64  `json:"weight" binding:"gte=0"`
 }
 
 type ProductVariantResponse struct {
@@ -29,6 +34,7 @@ type ProductVariantResponse struct {
 	ProductID uint   `json:"product_id"`
 	Name      string `json:"name"`
 	Price     int64  `json:"price"`
+	Weight    int64  `json:"weight,omitempty"`
 	// Stock     int64  `json:"stock"`
 }
 
@@ -38,33 +44,102 @@ func NewProductVariantResponse(m *model.ProductVariant) *ProductVariantResponse
 		ProductID: m.ProductID,
 		Name:      m.Name,
 		Price:     m.Price,
+		Weight:    m.Weight,
 		// Stock:     m.Stock,
 	}
 }
 
+// ProductListQuery filters the catalog. A category includes its descendants;
+// in_stock keeps products with a variant in stock or without variants.
+type ProductListQuery struct {
+	CategoryID  *uint  `form:"category_id" validate:"omitempty,gt=0"`
+	MinPrice    *int64 `form:"min_price" validate:"omitempty,gte=0"`
+	MaxPrice    *int64 `form:"max_price" validate:"omitempty,gte=0"`
+	InStock     bool   `form:"in_stock"`
+	HasVariants *bool  `form:"has_variants"`
+	Tag         string `form:"tag" validate:"max=100"`
+}
+
+// ProductSearchQuery searches products by name and description, within the
+// catalog filters.
+type ProductSearchQuery struct {
+	Q string `form:"q" validate:"max=100"`
+	ProductListQuery
+}
+
+// ProductListResponse is a page of products with the facets of every product
+// matching the filters, for the Mini App to render filter chips.
+type ProductListResponse struct {
+	PaginationResponse[ProductResponse]
+	Facets *ProductFacetsResponse `json:"facets"`
+}
+
+// ProductFacetsResponse counts the matching products by the values of each
+// filter. A facet ignores its own filter, so it shows what choosing another
+// value would give.
+type ProductFacetsResponse struct {
+	Categories  []CategoryFacetResponse `json:"categories"`
+	Tags        []TagFacetResponse      `json:"tags"`
+	InStock     int64                   `json:"in_stock"`
+	HasVariants int64                   `json:"has_variants"`
+	MinPrice    *int64                  `json:"min_price,omitempty"`
+	MaxPrice    *int64                  `json:"max_price,omitempty"`
+}
+
+type CategoryFacetResponse struct {
+	CategoryID uint  `json:"category_id"`
+	Count      int64 `json:"count"`
+}
+
+type TagFacetResponse struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
 type CreateProductRequest struct {
 	Name        string                        `json:"name" binding:"required,min=1,max=255"`
+	Slug        string                        `json:"slug,omitempty" binding:"max=255"`
 	Description string                        `json:"description"`
 	Price       int64                         `json:"price" binding:"required,gt=0"`
+	Weight      int64                         `json:"weight" binding:"gte=0"`
 	CategoryID  uint                          `json:"category_id" binding:"required,gt=0"`
 	Variants    []CreateProductVariantRequest `json:"variants,omitempty"`
 	Images      []AttachProductImageRequest   `json:"images,omitempty"`
+	Tags        []string                      `json:"tags,omitempty" binding:"omitempty,dive,max=100"`
+
+	MetaTitle       *string `json:"meta_title,omitempty" binding:"omitempty,max=255"`
+	MetaDescription *string `json:"meta_description,omitempty" binding:"omitempty,max=500"`
+
+	// Status defaults to published, putting the product on sale at once.
+	Status      string     `json:"status,omitempty" binding:"omitempty,oneof=draft scheduled published"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time `json:"unpublish_at,omitempty"`
 }
 
 func (p *CreateProductRequest) ToModel() *model.Product {
 	product := &model.Product{
-		Name:        p.Name,
-		Description: &p.Description,
-		Price:       p.Price,
+		Name:            p.Name,
+		Description:     &p.Description,
+		Price:           p.Price,
+		Weight:          p.Weight,
+		MetaTitle:       p.MetaTitle,
+		MetaDescription: p.MetaDescription,
+		Status:          model.ProductStatus(p.Status),
+		PublishAt:       p.PublishAt,
+		UnpublishAt:     p.UnpublishAt,
+	}
+	if product.Status == "" {
+		product.Status = model.ProductStatusPublished
 	}
 
 	if len(p.Variants) > 0 {
 		var variants []model.ProductVariant
 		for _, v := range p.Variants {
 			variants = append(variants, model.ProductVariant{
-				Name:  v.Name,
-				Price: v.Price,
-				Stock: v.Stock,
+				Name:   v.Name,
+				Price:  v.Price,
+				Stock:  v.Stock,
+				Weight: v.Weight,
 			})
 		}
 		product.Variants = variants
@@ -88,19 +163,47 @@ func (p *CreateProductRequest) ToModel() *model.Product {
 type UpdateProductRequest struct {
 	ID          uint    `json:"id,omitempty"`
 	Name        *string `json:"name,omitempty"`
+	Slug        *string `json:"slug,omitempty" binding:"omitempty,max=255"`
 	Description *string `json:"description,omitempty"`
 	Price       *int64  `json:"price,omitempty"`
+	Weight      *int64  `json:"weight,omitempty"`
 	CategoryID  *uint   `json:"category_id,omitempty"`
+	// Tags replaces the product's tags when present; an empty list clears them.
+	Tags []string `json:"tags,omitempty" binding:"omitempty,dive,max=100"`
+
+	MetaTitle       *string `json:"meta_title,omitempty" binding:"omitempty,max=255"`
+	MetaDescription *string `json:"meta_description,omitempty" binding:"omitempty,max=500"`
+}
+
+// UpdateProductPublicationRequest replaces the status and schedule of a
+// product; a time left out is cleared.
+type UpdateProductPublicationRequest struct {
+	Status      string     `json:"status" binding:"required,oneof=draft scheduled published"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time `json:"unpublish_at,omitempty"`
 }
 
 type ProductResponse struct {
 	ID          uint                     `json:"id"`
 	CategoryID  uint                     `json:"category_id"`
 	Name        string                   `json:"name"`
+	Slug        string                   `json:"slug"`
 	Description string                   `json:"description"`
 	Price       int64                    `json:"price"`
+	Weight      int64                    `json:"weight,omitempty"`
 	Variants    []ProductVariantResponse `json:"variants,omitempty"`
 	Images      []ProductImageResponse   `json:"images,omitempty"`
+	Tags        []string                 `json:"tags,omitempty"`
+
+	MetaTitle       *string `json:"meta_title,omitempty"`
+	MetaDescription *string `json:"meta_description,omitempty"`
+
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time `json:"unpublish_at,omitempty"`
+
+	// ArchivedAt is set for products in the trash.
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
 }
 
 func NewProductResponse(m *model.Product) *ProductResponse {
@@ -123,14 +226,36 @@ func NewProductResponse(m *model.Product) *ProductResponse {
 		}
 	}
 
+	var tags []string
+	for _, tag := range m.Tags {
+		tags = append(tags, tag.Name)
+	}
+
+	var archivedAt *time.Time
+	if m.DeletedAt.Valid {
+		archivedAt = &m.DeletedAt.Time
+	}
+
 	return &ProductResponse{
 		ID:          m.ID,
 		CategoryID:  m.CategoryID,
 		Name:        m.Name,
+		Slug:        m.Slug,
 		Description: desc,
 		Price:       m.Price,
+		Weight:      m.Weight,
 		Variants:    variant,
 		Images:      images,
+		Tags:        tags,
+
+		MetaTitle:       m.MetaTitle,
+		MetaDescription: m.MetaDescription,
+
+		Status:      string(m.Status),
+		PublishAt:   m.PublishAt,
+		UnpublishAt: m.UnpublishAt,
+
+		ArchivedAt: archivedAt,
 	}
 }
 
@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type ReconciliationQuery struct {
+	From   string `form:"from" validate:"required"`
+	To     string `form:"to" validate:"required"`
+	Zalo   bool   `form:"zalo"`
+	Format string `form:"format" validate:"omitempty,oneof=json csv"`
+}
+
+// Kinds of reconciliation mismatch.
+const (
+	// An order taken as paid with no bank transaction applied to it.
+	MismatchMissingBankTransaction = "missing_bank_transaction"
+	// A received transfer that was not applied to any order.
+	MismatchUnmatchedBankTransaction = "unmatched_bank_transaction"
+	// Paid, transferred and order amounts that do not agree.
+	MismatchAmount = "amount_mismatch"
+	// The same transfer received more than once.
+	MismatchDuplicateBankTransaction = "duplicate_bank_transaction"
+	// Zalo's payment result disagrees with the order.
+	MismatchZaloStatus = "zalo_status_mismatch"
+)
+
+type ReconciliationMismatch struct {
+	Type              string           `json:"type"`
+	OrderID           string           `json:"order_id,omitempty"`
+	BankTransactionID *uint            `json:"bank_transaction_id,omitempty"`
+	ZaloOrderID       string           `json:"zalo_order_id,omitempty"`
+	Expected          *decimal.Decimal `json:"expected,omitempty"`
+	Actual            *decimal.Decimal `json:"actual,omitempty"`
+	Detail            string           `json:"detail"`
+}
+
+type ReconciliationReport struct {
+	From                    time.Time                 `json:"from"`
+	To                      time.Time                 `json:"to"`
+	OrdersChecked           int                       `json:"orders_checked"`
+	BankTransactionsChecked int                       `json:"bank_transactions_checked"`
+	ZaloOrdersChecked       int                       `json:"zalo_orders_checked"`
+	Mismatches              []*ReconciliationMismatch `json:"mismatches"`
+}
+
+// CSV renders the mismatches of the report, one per row.
+func (r *ReconciliationReport) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"type", "order_id", "bank_transaction_id", "zalo_order_id", "expected", "actual", "detail"}); err != nil {
+		return nil, err
+	}
+
+	for _, m := range r.Mismatches {
+		row := []string{m.Type, m.OrderID, "", m.ZaloOrderID, "", "", m.Detail}
+		if m.BankTransactionID != nil {
+			row[2] = strconv.FormatUint(uint64(*m.BankTransactionID), 10)
+		}
+		if m.Expected != nil {
+			row[4] = m.Expected.StringFixed(0)
+		}
+		if m.Actual != nil {
+			row[5] = m.Actual.StringFixed(0)
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
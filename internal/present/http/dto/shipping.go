@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+)
+
+// ShippingRuleRequest creates a rule or replaces every setting of an existing
+// one. Weights are in grams and fees in VND.
+type ShippingRuleRequest struct {
+	Name                  string `json:"name" validate:"required,max=255"`
+	Province              string `json:"province" validate:"max=100"`
+	MinWeight             int64  `json:"min_weight" validate:"gte=0"`
+	MaxWeight             *int64 `json:"max_weight" validate:"omitempty,gt=0"`
+	BaseFee               int64  `json:"base_fee" validate:"gte=0"`
+	IncludedWeight        int64  `json:"included_weight" validate:"gte=0"`
+	FeePerKg              int64  `json:"fee_per_kg" validate:"gte=0"`
+	FreeShippingThreshold *int64 `json:"free_shipping_threshold" validate:"omitempty,gte=0"`
+	Priority              int    `json:"priority"`
+	Active                *bool  `json:"active"`
+}
+
+func (r *ShippingRuleRequest) Apply(rule *model.ShippingRule) {
+	rule.Name = r.Name
+	rule.Province = r.Province
+	rule.MinWeight = r.MinWeight
+	rule.MaxWeight = r.MaxWeight
+	rule.BaseFee = r.BaseFee
+	rule.IncludedWeight = r.IncludedWeight
+	rule.FeePerKg = r.FeePerKg
+	rule.FreeShippingThreshold = r.FreeShippingThreshold
+	rule.Priority = r.Priority
+	rule.Active = r.Active == nil || *r.Active
+}
+
+type ShippingQuoteRequest struct {
+	Province    string             `json:"province" validate:"max=100"`
+	Items       []OrderItemRequest `json:"items" validate:"required,min=1,dive"`
+	VoucherCode string             `json:"voucher_code" validate:"max=50"`
+}
+
+type ShippingQuoteResponse struct {
+	RuleID                uint   `json:"rule_id"`
+	RuleName              string `json:"rule_name"`
+	Weight                int64  `json:"weight"`
+	Subtotal              int64  `json:"subtotal"`
+	DiscountAmount        int64  `json:"discount_amount"`
+	ShippingFee           int64  `json:"shipping_fee"`
+	TotalAmount           int64  `json:"total_amount"`
+	FreeShippingThreshold *int64 `json:"free_shipping_threshold,omitempty"`
+}
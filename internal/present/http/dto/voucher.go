@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+)
+
+// Amounts are whole VND. Value is a percentage (1-100) for percent vouchers.
+type CreateVoucherRequest struct {
+	Code          string     `json:"code" validate:"required,max=50"`
+	Description   string     `json:"description"`
+	Type          string     `json:"type" validate:"required,oneof=percent fixed"`
+	Value         int64      `json:"value" validate:"required,gt=0"`
+	MaxDiscount   *int64     `json:"max_discount" validate:"omitempty,gt=0"`
+	MinOrderValue int64      `json:"min_order_value" validate:"gte=0"`
+	StartsAt      *time.Time `json:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at"`
+	UsageLimit    *int       `json:"usage_limit" validate:"omitempty,gt=0"`
+	PerPhoneLimit *int       `json:"per_phone_limit" validate:"omitempty,gt=0"`
+	Active        *bool      `json:"active"`
+	ProductIDs    []uint     `json:"product_ids"`
+	CategoryIDs   []uint     `json:"category_ids"`
+}
+
+// Fields left out are unchanged. A zero max_discount, usage_limit or
+// per_phone_limit removes the limit, and a zero starts_at or ends_at
+// ("0001-01-01T00:00:00Z") removes the date.
+type UpdateVoucherRequest struct {
+	Code          *string    `json:"code" validate:"omitempty,max=50"`
+	Description   *string    `json:"description"`
+	Type          *string    `json:"type" validate:"omitempty,oneof=percent fixed"`
+	Value         *int64     `json:"value" validate:"omitempty,gt=0"`
+	MaxDiscount   *int64     `json:"max_discount" validate:"omitempty,gte=0"`
+	MinOrderValue *int64     `json:"min_order_value" validate:"omitempty,gte=0"`
+	StartsAt      *time.Time `json:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at"`
+	UsageLimit    *int       `json:"usage_limit" validate:"omitempty,gte=0"`
+	PerPhoneLimit *int       `json:"per_phone_limit" validate:"omitempty,gte=0"`
+	Active        *bool      `json:"active"`
+	ProductIDs    *[]uint    `json:"product_ids"`
+	CategoryIDs   *[]uint    `json:"category_ids"`
+}
+
+type VoucherDetailResponse struct {
+	*model.Voucher
+	Usages []*model.VoucherUsage `json:"usages"`
+}
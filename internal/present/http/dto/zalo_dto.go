@@ -1,9 +1,10 @@
 package dto
 
 type NofityCallbackData struct {
-	OrderID string `json:"orderId"`
-	Method  string `json:"method"`
-	AppID   string `json:"appId"`
+	OrderID   string `json:"orderId"`
+	Method    string `json:"method"`
+	AppID     string `json:"appId"`
+	Extradata string `json:"extradata"`
 }
 
 type NofityCallbackRequest struct {
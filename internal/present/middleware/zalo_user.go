@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/log"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	zaloAccessTokenHeader = "X-Zalo-Access-Token"
+	zaloPhoneTokenHeader  = "X-Zalo-Phone-Token"
+)
+
+// ZaloUserAuth requires a Zalo access token and stores the customer it
+// belongs to in the request context.
+func ZaloUserAuth(authService *services.AuthService) gin.HandlerFunc {
+	return zaloUser(authService, true)
+}
+
+// ZaloUserOptional resolves the customer when a Zalo access token is sent and
+// lets anonymous requests through.
+func ZaloUserOptional(authService *services.AuthService) gin.HandlerFunc {
+	return zaloUser(authService, false)
+}
+
+func zaloUser(authService *services.AuthService, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessToken := strings.TrimSpace(c.GetHeader(zaloAccessTokenHeader))
+		if accessToken == "" {
+			if required {
+				log.Warn(c.Request.Context(), "Missing Zalo access token")
+				abortWithError(c, common.ErrUnauthorized(c.Request.Context()))
+				return
+			}
+			c.Next()
+			return
+		}
+
+		user, err := authService.ResolveZaloUser(c.Request.Context(), accessToken, strings.TrimSpace(c.GetHeader(zaloPhoneTokenHeader)))
+		if err != nil {
+			log.IErr(c.Request.Context(), err)
+			abortWithError(c, err)
+			return
+		}
+
+		c.Request = c.Request.WithContext(common.WithZaloUser(c.Request.Context(), user))
+		c.Next()
+	}
+}
+
+func abortWithError(c *gin.Context, err *common.Error) {
+	c.JSON(err.GetHttpStatus(), common.ConvertErrorToResponse(err))
+	c.Abort()
+}
@@ -35,3 +35,23 @@ func (s *AuthService) DecodePhoneNumber(ctx context.Context, accessToken string,
 
 	return phoneNumber, nil
 }
+
+// ResolveZaloUser identifies the customer behind a mini app access token. The
+// phone number is decoded as well when the app sends a phone token.
+func (s *AuthService) ResolveZaloUser(ctx context.Context, accessToken string, phoneToken string) (*common.ZaloUser, *common.Error) {
+	userInfo, err := s.zaloClient.GetUserInfo(ctx, accessToken, s.cfg.ZaloAppSecret)
+	if err != nil {
+		return nil, common.ErrUnauthorized(ctx).SetDetail(err.Error())
+	}
+
+	user := &common.ZaloUser{ID: userInfo.ID}
+	if phoneToken != "" {
+		phoneNumber, errSvc := s.DecodePhoneNumber(ctx, accessToken, phoneToken)
+		if errSvc != nil {
+			return nil, errSvc
+		}
+		user.Phone = phoneNumber.Data.Number
+	}
+
+	return user, nil
+}
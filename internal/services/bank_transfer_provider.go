@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/config"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/log"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/utils"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/client/zalo/payment"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+	"github.com/skip2/go-qrcode"
+)
+
+// BankTransferProvider takes payments by transfer into the shop's bank
+// account. The bank webhook settles the order, which is then reported to
+// Zalo.
+type BankTransferProvider struct {
+	*ZaloCheckoutProvider
+}
+
+func NewBankTransferProvider(checkout *ZaloCheckoutProvider) *BankTransferProvider {
+	return &BankTransferProvider{ZaloCheckoutProvider: checkout}
+}
+
+func (p *BankTransferProvider) Method() PaymentMethod {
+	return PaymentMethodBank
+}
+
+// Initiate adds a VietQR for the transfer to the checkout parameters. The
+// order stands without one.
+func (p *BankTransferProvider) Initiate(ctx context.Context, order *model.Order) (*PaymentInstructions, *common.Error) {
+	instructions, err := p.ZaloCheckoutProvider.Initiate(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	qr, err := buildVietQR(ctx, p.cfg, order)
+	if err != nil {
+		log.Warn(ctx, "Initiate: no VietQR for order %s: %v", order.ID, err)
+	}
+	instructions.VietQR = qr
+	return instructions, nil
+}
+
+func (p *BankTransferProvider) ReportResult(ctx context.Context, order *model.Order, resultCode int) error {
+	return p.sendOrderResult(ctx, order, resultCode, p.zaloPaymentClient.UpdateBankOrderStatus)
+}
+
+func (p *BankTransferProvider) Refund(ctx context.Context, order *model.Order) error {
+	return p.ReportResult(ctx, order, payment.ResultCodeRefunded)
+}
+
+// vietQRImageSize is the width and height of VietQR PNGs in pixels.
+const vietQRImageSize = 512
+
+// buildVietQR makes the VietQR for paying what is still due on a bank
+// transfer order into the account configured in cfg, with the order ID as the
+// transfer content so the bank webhook can match the transfer. Only an order
+// waiting for payment with money still due gets one.
+func buildVietQR(ctx context.Context, cfg *config.Config, order *model.Order) (*dto.VietQRResponse, *common.Error) {
+	if PaymentMethod(payment.BaseMethod(order.PaymentMethod)) != PaymentMethodBank {
+		return nil, common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("order %s is not paid by bank transfer", order.ID))
+	}
+	if cfg.BankBIN == "" || cfg.BankAccountNumber == "" {
+		return nil, common.ErrSystemError(ctx, "bank account for VietQR is not configured").SetSource(common.CurrentService)
+	}
+
+	if order.Status != model.OrderStatusPaying {
+		return nil, common.ErrConflict(ctx, "Order", fmt.Sprintf("is not waiting for payment once %s", order.Status))
+	}
+	due := order.TotalAmount.Sub(order.PaidAmount)
+	if !due.IsPositive() {
+		return nil, common.ErrConflict(ctx, "Order", "is already paid")
+	}
+
+	qr := &utils.VietQR{
+		BankBIN:       cfg.BankBIN,
+		AccountNumber: cfg.BankAccountNumber,
+		Amount:        due.Ceil().IntPart(),
+		Content:       order.ID,
+	}
+	payload := qr.Payload()
+	png, err := qrcode.Encode(payload, qrcode.Medium, vietQRImageSize)
+	if err != nil {
+		return nil, common.ErrSystemError(ctx, err.Error()).SetSource(common.CurrentService)
+	}
+
+	return &dto.VietQRResponse{
+		BankBIN:       qr.BankBIN,
+		AccountNumber: qr.AccountNumber,
+		AccountName:   cfg.BankAccountName,
+		Amount:        qr.Amount,
+		Content:       qr.Content,
+		Payload:       payload,
+		Image:         "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
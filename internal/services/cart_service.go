@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/log"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/repositories"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+)
+
+type CartService struct {
+	cartRepository    *repositories.CartRepository
+	productRepository *repositories.ProductRepository
+	orderService      *OrderService
+}
+
+func NewCartService(cartRepo *repositories.CartRepository, productRepo *repositories.ProductRepository, orderService *OrderService) *CartService {
+	return &CartService{
+		cartRepository:    cartRepo,
+		productRepository: productRepo,
+		orderService:      orderService,
+	}
+}
+
+// GetCart returns the cart of the Zalo user in ctx, priced and stock-checked
+// against the current catalog.
+func (s *CartService) GetCart(ctx context.Context) (*dto.CartResponse, *common.Error) {
+	cart, err := s.currentCart(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.priceCart(ctx, cart)
+}
+
+func (s *CartService) AddItem(ctx context.Context, req *dto.AddCartItemRequest) (*dto.CartResponse, *common.Error) {
+	cart, err := s.currentCart(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepository.GetProductDetailByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if !product.IsVisibleAt(time.Now()) {
+		return nil, common.ErrNotFound(ctx, "Product", "not found")
+	}
+	if _, err := resolveVariant(ctx, product, req.VariantID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.cartRepository.AddItem(ctx, cart.ID, req.ProductID, req.VariantID, req.Quantity); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx)
+}
+
+// UpdateItem sets the quantity of a cart item; zero removes it.
+func (s *CartService) UpdateItem(ctx context.Context, itemID uint, req *dto.UpdateCartItemRequest) (*dto.CartResponse, *common.Error) {
+	cart, err := s.currentCart(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Quantity == 0 {
+		err = s.cartRepository.RemoveItem(ctx, cart.ID, itemID)
+	} else {
+		err = s.cartRepository.UpdateItemQuantity(ctx, cart.ID, itemID, req.Quantity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx)
+}
+
+func (s *CartService) RemoveItem(ctx context.Context, itemID uint) (*dto.CartResponse, *common.Error) {
+	cart, err := s.currentCart(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepository.RemoveItem(ctx, cart.ID, itemID); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx)
+}
+
+func (s *CartService) ClearCart(ctx context.Context) *common.Error {
+	cart, err := s.currentCart(ctx)
+	if err != nil {
+		return err
+	}
+	return s.cartRepository.ClearCart(ctx, cart.ID)
+}
+
+// Checkout turns the cart into an order through OrderService.CreateOrder and
+// empties the cart once the order exists. Carts with items that can no longer
+// be bought are refused so the customer can review them first.
+func (s *CartService) Checkout(ctx context.Context, req *dto.CheckoutCartRequest) (*dto.CreateOrderResponse, *common.Error) {
+	cart, err := s.currentCart(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, common.ErrBadRequest(ctx).SetDetail("cart is empty")
+	}
+
+	priced, err := s.priceCart(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+	if priced.HasIssues {
+		var names []string
+		for _, item := range priced.Items {
+			if item.Issue != "" {
+				names = append(names, fmt.Sprintf("%s (%s)", item.Name, item.Issue))
+			}
+		}
+		return nil, common.ErrConflict(ctx, "Cart", "has items that cannot be ordered").SetDetail(strings.Join(names, ", "))
+	}
+
+	orderReq := &dto.CreateOrderRequest{
+		CustomerInfo: req.CustomerInfo,
+		Payment:      req.Payment,
+		VoucherCode:  req.VoucherCode,
+	}
+	for _, item := range cart.Items {
+		orderReq.Items = append(orderReq.Items, dto.OrderItemRequest{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	res, err := s.orderService.CreateOrder(ctx, orderReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepository.ClearCart(ctx, cart.ID); err != nil {
+		log.Error(ctx, "Checkout: order %s created but cart %d was not cleared: %s", res.ID, cart.ID, err.GetDetail())
+	}
+	return res, nil
+}
+
+func (s *CartService) currentCart(ctx context.Context) (*model.Cart, *common.Error) {
+	user := common.GetZaloUser(ctx)
+	if user == nil {
+		return nil, common.ErrUnauthorized(ctx)
+	}
+	return s.cartRepository.GetOrCreateCart(ctx, user.ID)
+}
+
+// priceCart prices every item at the current product or variant price and
+// flags items whose product, variant or stock no longer allows ordering them.
+func (s *CartService) priceCart(ctx context.Context, cart *model.Cart) (*dto.CartResponse, *common.Error) {
+	ids := make([]uint, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		ids = append(ids, item.ProductID)
+	}
+
+	products, err := s.productRepository.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	// Products taken off the storefront are unavailable like deleted ones
+	now := time.Now()
+	productByID := make(map[uint]*model.Product, len(products))
+	for _, product := range products {
+		if product.IsVisibleAt(now) {
+			productByID[product.ID] = product
+		}
+	}
+
+	res := &dto.CartResponse{
+		ID:    cart.ID,
+		Items: make([]dto.CartItemResponse, 0, len(cart.Items)),
+	}
+	for _, item := range cart.Items {
+		itemRes := dto.CartItemResponse{
+			ID:        item.ID,
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		}
+
+		product, ok := productByID[item.ProductID]
+		if !ok {
+			itemRes.Issue = dto.CartItemIssueProductUnavailable
+		} else {
+			itemRes.Name = product.Name
+			if len(product.ProductImages) > 0 && product.ProductImages[0].Image != nil {
+				itemRes.ImageURL = product.ProductImages[0].Image.URL
+			}
+
+			variant, errVariant := resolveVariant(ctx, product, item.VariantID)
+			switch {
+			case errVariant != nil && item.VariantID == nil:
+				itemRes.Issue = dto.CartItemIssueVariantRequired
+			case errVariant != nil:
+				itemRes.Issue = dto.CartItemIssueVariantUnavailable
+			default:
+				itemRes.UnitPrice = unitPrice(product, variant)
+				itemRes.LineTotal = itemRes.UnitPrice * int64(item.Quantity)
+				if variant != nil {
+					itemRes.VariantName = variant.Name
+					stock := variant.Stock
+					itemRes.Stock = &stock
+					if stock <= 0 {
+						itemRes.Issue = dto.CartItemIssueOutOfStock
+					} else if stock < int64(item.Quantity) {
+						itemRes.Issue = dto.CartItemIssueInsufficientStock
+					}
+				}
+			}
+		}
+
+		if itemRes.Issue != "" {
+			res.HasIssues = true
+		} else {
+			res.Subtotal += itemRes.LineTotal
+			res.TotalQuantity += item.Quantity
+		}
+		res.Items = append(res.Items, itemRes)
+	}
+
+	return res, nil
+}
@@ -33,6 +33,12 @@ func (s *CategoryService) CreateCategory(ctx context.Context, req *dto.CreateCat
 		return common.ErrConflict(ctx, "Category", "Category already exists")
 	}
 
+	if req.ParentID != nil {
+		if err := s.checkParent(ctx, 0, *req.ParentID); err != nil {
+			return err
+		}
+	}
+
 	category := req.ToModel()
 	if req.ImageID != nil {
 		category.ImageID = req.ImageID
@@ -67,6 +73,17 @@ func (s *CategoryService) UpdateCategory(ctx context.Context, id uint, req *dto.
 	if req.ImageID != nil {
 		category.ImageID = req.ImageID
 	}
+	if req.ParentID != nil {
+		// 0 moves the category to the top level
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			if err := s.checkParent(ctx, id, *req.ParentID); err != nil {
+				return nil, err
+			}
+			category.ParentID = req.ParentID
+		}
+	}
 
 	err = s.categoryRepository.UpdateCategory(ctx, category)
 	if err != nil {
@@ -80,17 +97,40 @@ func (s *CategoryService) DeleteCategory(ctx context.Context, id uint) *common.E
 	return s.categoryRepository.DeleteCategory(ctx, id)
 }
 
-func (s *CategoryService) GetProductsByCategory(ctx context.Context, categoryID uint, page, size int) (*dto.PaginationResponse[dto.ProductResponse], *common.Error) {
-	products, total, err := s.productRepository.GetProductsByCategoryID(ctx, categoryID, (page-1)*size, size)
-	if err != nil {
+// GetProductsByCategory lists the products of a category and its descendants
+// matching query, with their facets.
+func (s *CategoryService) GetProductsByCategory(ctx context.Context, categoryID uint, pagination *dto.PaginationRequest, query *dto.ProductListQuery) (*dto.ProductListResponse, *common.Error) {
+	if _, err := s.categoryRepository.GetCategoryByID(ctx, categoryID); err != nil {
 		return nil, err
 	}
 
-	var productResponses []dto.ProductResponse
-	for _, p := range products {
-		productResponses = append(productResponses, *dto.NewProductResponse(p))
+	filter := productFilterFromQuery(query)
+	filter.CategoryID = &categoryID
+	return listProducts(ctx, s.productRepository, pagination, filter)
+}
+
+// checkParent verifies that the category id, or a new category when id is 0,
+// can be placed under parentID: the parent must exist and must not be the
+// category itself or one of its descendants.
+func (s *CategoryService) checkParent(ctx context.Context, id uint, parentID uint) *common.Error {
+	if _, err := s.categoryRepository.GetCategoryByID(ctx, parentID); err != nil {
+		return err
+	}
+	if id == 0 {
+		return nil
+	}
+	if id == parentID {
+		return common.ErrBadRequest(ctx).SetDetail("a category cannot be placed under itself or its descendants")
 	}
 
-	response := dto.NewPaginationResponse(productResponses, total, dto.PaginationRequest{Page: page, Size: size})
-	return &response, nil
+	descendants, err := s.categoryRepository.GetDescendantIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, descendant := range descendants {
+		if descendant == parentID {
+			return common.ErrBadRequest(ctx).SetDetail("a category cannot be placed under itself or its descendants")
+		}
+	}
+	return nil
 }
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/client/zalo/payment"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+)
+
+// CodProvider takes payment in cash on delivery. Staff confirm the delivery,
+// which is then reported to Zalo.
+type CodProvider struct {
+	*ZaloCheckoutProvider
+}
+
+func NewCodProvider(checkout *ZaloCheckoutProvider) *CodProvider {
+	return &CodProvider{ZaloCheckoutProvider: checkout}
+}
+
+func (p *CodProvider) Method() PaymentMethod {
+	return PaymentMethodCod
+}
+
+// InitialStatus starts the order pending, as COD needs no upfront payment.
+func (p *CodProvider) InitialStatus() model.OrderStatus {
+	return model.OrderStatusPending
+}
+
+func (p *CodProvider) ReportResult(ctx context.Context, order *model.Order, resultCode int) error {
+	return p.sendOrderResult(ctx, order, resultCode, p.zaloPaymentClient.UpdateCodOrderStatus)
+}
+
+func (p *CodProvider) Refund(ctx context.Context, order *model.Order) error {
+	return p.ReportResult(ctx, order, payment.ResultCodeRefunded)
+}
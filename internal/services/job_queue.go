@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/config"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/log"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/repositories"
+)
+
+const (
+	defaultJobMaxAttempts = 12
+	jobPollInterval       = 5 * time.Second
+	// A job running longer than this is assumed to have lost its worker.
+	jobStaleAfter = 10 * time.Minute
+)
+
+// JobHandlers maps job types to the handlers of one service. Services with
+// background work provide theirs to fx in the job_handlers group, and the
+// queue registers them all before its workers start.
+type JobHandlers map[string]JobHandler
+
+// JobHandler runs one job. Returning an error schedules another attempt with
+// backoff, until the job runs out of attempts; an error wrapped with
+// PermanentJobError fails the job at once.
+type JobHandler func(ctx context.Context, job *model.Job) error
+
+type permanentJobError struct {
+	err error
+}
+
+func (e *permanentJobError) Error() string { return e.err.Error() }
+
+func (e *permanentJobError) Unwrap() error { return e.err }
+
+// PermanentJobError marks err as one that retrying cannot fix.
+func PermanentJobError(err error) error {
+	return &permanentJobError{err: err}
+}
+
+// JobQueue stores background jobs in Postgres and runs them on a pool of
+// workers, so scheduled work survives restarts.
+type JobQueue struct {
+	jobRepository *repositories.JobRepository
+	workers       int
+
+	mu       sync.RWMutex
+	handlers map[string]JobHandler
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewJobQueue(jobRepo *repositories.JobRepository, cfg *config.Config) *JobQueue {
+	workers := cfg.JobWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	return &JobQueue{
+		jobRepository: jobRepo,
+		workers:       workers,
+		handlers:      make(map[string]JobHandler),
+	}
+}
+
+// Register sets the handler for jobs of the given type.
+func (q *JobQueue) Register(jobType string, handler JobHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// Enqueue stores a job that runs no earlier than runAt. The payload is stored
+// as JSON for the handler to decode.
+func (q *JobQueue) Enqueue(ctx context.Context, jobType string, payload interface{}, runAt time.Time) *common.Error {
+	job, errJob := NewJob(ctx, jobType, payload, runAt)
+	if errJob != nil {
+		return errJob
+	}
+	if errRepo := q.jobRepository.CreateJob(ctx, job); errRepo != nil {
+		return errRepo
+	}
+
+	log.Debug(ctx, "JobQueue: enqueued %s job %d to run at %s", jobType, job.ID, runAt.Format(time.RFC3339))
+	return nil
+}
+
+// NewJob builds a job like Enqueue without storing it, for a repository to
+// save in the same transaction as the change the job follows up on.
+func NewJob(ctx context.Context, jobType string, payload interface{}, runAt time.Time) (*model.Job, *common.Error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, common.ErrSystemError(ctx, err.Error()).SetSource(common.CurrentService)
+	}
+
+	return &model.Job{
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      model.JobStatusPending,
+		RunAt:       runAt,
+		MaxAttempts: defaultJobMaxAttempts,
+	}, nil
+}
+
+// Start launches the workers. They keep polling for due jobs until Stop.
+func (q *JobQueue) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	host, _ := os.Hostname()
+	for i := 0; i < q.workers; i++ {
+		worker := fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i)
+		q.wg.Add(1)
+		go func(first bool) {
+			defer q.wg.Done()
+			q.work(ctx, worker, first)
+		}(i == 0)
+	}
+}
+
+// Stop asks the workers to finish and waits for them, or for ctx to end.
+// Jobs interrupted mid-run are picked up again once they go stale.
+func (q *JobQueue) Stop(ctx context.Context) error {
+	if q.cancel == nil {
+		return nil
+	}
+	q.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work polls for due jobs. The first worker also returns stale jobs to the
+// queue, so a single pool does that housekeeping once per poll.
+func (q *JobQueue) work(ctx context.Context, worker string, first bool) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if first {
+			requeued, failed, err := q.jobRepository.RequeueStaleJobs(ctx, time.Now().Add(-jobStaleAfter))
+			if err != nil {
+				log.Error(ctx, "JobQueue: failed to requeue stale jobs: %v", err)
+			} else if requeued > 0 || failed > 0 {
+				log.Warn(ctx, "JobQueue: requeued %d stale jobs, failed %d out of attempts", requeued, failed)
+			}
+		}
+		q.runDue(ctx, worker)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *JobQueue) runDue(ctx context.Context, worker string) {
+	for ctx.Err() == nil {
+		jobs, err := q.jobRepository.ClaimDueJobs(ctx, worker, 1)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error(ctx, "JobQueue: failed to claim jobs: %v", err)
+			}
+			return
+		}
+		if len(jobs) == 0 {
+			return
+		}
+		for _, job := range jobs {
+			q.run(ctx, job)
+		}
+	}
+}
+
+func (q *JobQueue) run(ctx context.Context, job *model.Job) {
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	var runErr error
+	if !ok {
+		runErr = fmt.Errorf("no handler registered for job type %q", job.Type)
+	} else {
+		runErr = handler(ctx, job)
+	}
+
+	// Record the outcome even when the run was cut short by Stop.
+	ctx = context.WithoutCancel(ctx)
+
+	var errRepo *common.Error
+	switch {
+	case runErr == nil:
+		errRepo = q.jobRepository.CompleteJob(ctx, job.ID)
+		log.Debug(ctx, "JobQueue: %s job %d done", job.Type, job.ID)
+	case ok && job.CanRetry() && !errors.As(runErr, new(*permanentJobError)):
+		delay := model.RetryDelay(job.Attempts)
+		errRepo = q.jobRepository.RetryJob(ctx, job.ID, time.Now().Add(delay), runErr.Error())
+		log.Warn(ctx, "JobQueue: %s job %d attempt %d/%d failed, retrying in %s: %v",
+			job.Type, job.ID, job.Attempts, job.MaxAttempts, delay, runErr)
+	default:
+		errRepo = q.jobRepository.FailJob(ctx, job.ID, runErr.Error())
+		log.Error(ctx, "JobQueue: %s job %d failed after %d attempts: %v", job.Type, job.ID, job.Attempts, runErr)
+	}
+	if errRepo != nil {
+		log.Error(ctx, "JobQueue: failed to record outcome of job %d: %v", job.ID, errRepo)
+	}
+}
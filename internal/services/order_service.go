@@ -2,13 +2,16 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/config"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/log"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/utils"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/client/zalo/payment"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/repositories"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
@@ -18,183 +21,552 @@ import (
 type OrderService struct {
 	orderRepository   *repositories.OrderRepository
 	productRepository *repositories.ProductRepository
+	paymentService    *PaymentService
+	paymentProviders  *PaymentProviders
+	voucherService    *VoucherService
+	shippingService   *ShippingService
 	cfg               *config.Config
 }
 
-func NewOrderService(orderRepo *repositories.OrderRepository, productRepo *repositories.ProductRepository, cfg *config.Config) *OrderService {
+func NewOrderService(orderRepo *repositories.OrderRepository, productRepo *repositories.ProductRepository, paymentService *PaymentService, paymentProviders *PaymentProviders, voucherService *VoucherService, shippingService *ShippingService, cfg *config.Config) *OrderService {
 	return &OrderService{
 		orderRepository:   orderRepo,
 		productRepository: productRepo,
+		paymentService:    paymentService,
+		paymentProviders:  paymentProviders,
+		voucherService:    voucherService,
+		shippingService:   shippingService,
 		cfg:               cfg,
 	}
 }
 
 func (s *OrderService) CreateOrder(ctx context.Context, req *dto.CreateOrderRequest) (*dto.CreateOrderResponse, *common.Error) {
-	// 1. Validate items and Calculate total
-	var orderItems []model.OrderItem
-	totalAmount := decimal.Zero
+	if !s.paymentProviders.Supports(req.Payment.Method) {
+		return nil, common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("unknown payment method %q", req.Payment.Method))
+	}
+
+	// 1. Price the items at current catalog prices
+	priced, err := s.priceItems(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Apply the voucher and shipping; voucher usage is counted when the order is saved
+	charges, err := s.computeCharges(ctx, priced, req.VoucherCode, req.CustomerInfo.Province)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Create Order Model
+	custInfo := &model.CustomerInfo{
+		Name:     req.CustomerInfo.Name,
+		Phone:    req.CustomerInfo.Phone,
+		Address:  req.CustomerInfo.Address,
+		Province: req.CustomerInfo.Province,
+	}
+
+	orderID := utils.GenerateUniqueOrderID()
+
+	provider := s.paymentProviders.For(req.Payment.Method)
+
+	order := &model.Order{
+		ID:             orderID,
+		CustomerInfo:   custInfo,
+		Subtotal:       priced.subtotal,
+		DiscountAmount: charges.discount,
+		ShippingFee:    charges.shippingFee,
+		TotalAmount:    priced.subtotal.Sub(charges.discount).Add(charges.shippingFee),
+		Status:         provider.InitialStatus(),
+		PaymentMethod:  req.Payment.Method,
+		OrderItems:     priced.orderItems,
+	}
+	order.Payments = []model.Payment{{
+		Method: req.Payment.Method,
+		Amount: order.TotalAmount,
+		Status: model.PaymentAttemptPending,
+	}}
+	if charges.voucher != nil {
+		order.VoucherID = &charges.voucher.ID
+		order.VoucherCode = &charges.voucher.Code
+	}
+	if user := common.GetZaloUser(ctx); user != nil {
+		order.ZaloUserID = &user.ID
+	}
+
+	// 4. Save to DB, reserving variant stock and redeeming the voucher in the same transaction
+	event := &model.OrderEvent{
+		Source: model.OrderEventSourceCheckout,
+		Actor:  custInfo.Phone,
+	}
+	if err := s.orderRepository.CreateOrder(ctx, order, event); err != nil {
+		return nil, err
+	}
+
+	// 5. Let the provider of the method set up the payment
+	instructions, err := provider.Initiate(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.CreateOrderResponse{
+		Order:      order,
+		ZaloParams: instructions.ZaloParams,
+		VietQR:     instructions.VietQR,
+	}
+	if instructions.ZaloParams != nil {
+		res.MAC = instructions.ZaloParams.Mac
+	}
 
-	for _, itemReq := range req.Items {
+	return res, nil
+}
+
+// pricedItems is an order's items priced at current catalog prices, with
+// their total before discount and shipping weight in grams.
+type pricedItems struct {
+	orderItems   []model.OrderItem
+	voucherLines []voucherLine
+	subtotal     decimal.Decimal
+	weight       int64
+}
+
+func (s *OrderService) priceItems(ctx context.Context, items []dto.OrderItemRequest) (*pricedItems, *common.Error) {
+	priced := &pricedItems{subtotal: decimal.Zero}
+
+	for _, itemReq := range items {
 		// Get Product details for snapshot
 		product, err := s.productRepository.GetProductByID(ctx, itemReq.ProductID)
 		if err != nil {
 			return nil, err
 		}
-		if product == nil {
+		if product == nil || !product.IsVisibleAt(time.Now()) {
 			return nil, common.ErrNotFound(ctx, "Product", "not found")
 		}
 
+		variant, err := resolveVariant(ctx, product, itemReq.VariantID)
+		if err != nil {
+			return nil, err
+		}
+
+		price := decimal.NewFromInt(unitPrice(product, variant))
+
 		// Create Snapshot
 		snapshot := &model.ProductSnapshot{
 			ProductID:   product.ID,
 			Name:        product.Name,
-			Price:       decimal.NewFromInt(product.Price),
+			Price:       price,
 			Description: "", // Optional
 		}
+		if variant != nil {
+			snapshot.VariantID = &variant.ID
+			snapshot.VariantName = variant.Name
+		}
 		if product.Description != nil {
 			snapshot.Description = *product.Description
 		}
-		// Assuming first image as main image for snapshot, or existing logic
-		if len(product.ProductImages) > 0 {
-			// This logic depends on how images are loaded.
-			// ProductRepository.GetProductByID might need to preload images if we want them in snapshot.
-			// Let's assume for now we might need to fetch images if not preloaded, or just skip if complex.
-			// Checking ProductRepository.GetProductByID implementation:
-			// It calls GetProductDetailByID which likely preloads.
-			// Let's check the product model again. ProductImages []ProductImage.
-			for _, img := range product.ProductImages {
-				if img.IsMain && img.Image != nil {
-					snapshot.ImageURL = img.Image.URL // Assuming Image model has Url
-					break
-				}
-			}
-			// Fallback if no main image found but images exist
-			if snapshot.ImageURL == "" && len(product.ProductImages) > 0 && product.ProductImages[0].Image != nil {
-				snapshot.ImageURL = product.ProductImages[0].Image.URL
+		// GetProductByID preloads the images; the snapshot keeps the main one,
+		// or the first image when none is marked main
+		for _, img := range product.ProductImages {
+			if img.IsMain && img.Image != nil {
+				snapshot.ImageURL = img.Image.URL
+				break
 			}
 		}
+		if snapshot.ImageURL == "" && len(product.ProductImages) > 0 && product.ProductImages[0].Image != nil {
+			snapshot.ImageURL = product.ProductImages[0].Image.URL
+		}
 
-		price := decimal.NewFromInt(product.Price)
 		quantity := decimal.NewFromInt(int64(itemReq.Quantity))
 		lineTotal := price.Mul(quantity)
-		totalAmount = totalAmount.Add(lineTotal)
+		priced.subtotal = priced.subtotal.Add(lineTotal)
+		priced.weight += itemWeight(product, variant) * int64(itemReq.Quantity)
+		priced.voucherLines = append(priced.voucherLines, voucherLine{
+			ProductID:  product.ID,
+			CategoryID: product.CategoryID,
+			Total:      lineTotal,
+		})
 
-		orderItems = append(orderItems, model.OrderItem{
+		priced.orderItems = append(priced.orderItems, model.OrderItem{
+			VariantID:       snapshot.VariantID,
 			ProductSnapshot: snapshot,
 			Quantity:        itemReq.Quantity,
 			Price:           price,
 		})
 	}
 
-	// 2. Create Order Model
-	custInfo := &model.CustomerInfo{
-		Name:    req.CustomerInfo.Name,
-		Phone:   req.CustomerInfo.Phone,
-		Address: req.CustomerInfo.Address,
-	}
+	return priced, nil
+}
 
-	orderID := utils.GenerateUniqueOrderID()
+// orderCharges is what is taken off and added to an order's subtotal.
+type orderCharges struct {
+	voucher      *model.Voucher
+	discount     decimal.Decimal
+	shippingRule *model.ShippingRule
+	shippingFee  decimal.Decimal
+}
 
-	orderStatus := model.OrderStatusFailed
-	switch req.Payment.Method {
-	case "BANK":
-		orderStatus = model.OrderStatusPaying
-	case "COD":
-		orderStatus = model.OrderStatusPending
+// computeCharges applies the voucher, if any, and prices shipping to province.
+// Free shipping thresholds are compared with the subtotal after discount.
+func (s *OrderService) computeCharges(ctx context.Context, priced *pricedItems, voucherCode string, province string) (*orderCharges, *common.Error) {
+	charges := &orderCharges{discount: decimal.Zero}
+	if voucherCode != "" {
+		voucher, discount, err := s.voucherService.applyVoucher(ctx, voucherCode, priced.voucherLines)
+		if err != nil {
+			return nil, err
+		}
+		charges.voucher, charges.discount = voucher, discount
 	}
 
-	order := &model.Order{
-		ID:           orderID,
-		CustomerInfo: custInfo,
-		TotalAmount:  totalAmount,
-		Status:       orderStatus,
-		OrderItems:   orderItems,
+	goodsValue := priced.subtotal.Sub(charges.discount).IntPart()
+	rule, fee, err := s.shippingService.quote(ctx, province, priced.weight, goodsValue)
+	if err != nil {
+		return nil, err
 	}
+	charges.shippingRule, charges.shippingFee = rule, decimal.NewFromInt(fee)
 
-	// 3. Save to DB
-	if err := s.orderRepository.CreateOrder(ctx, order); err != nil {
+	return charges, nil
+}
+
+// QuoteShipping prices an order before it is placed: subtotal, voucher
+// discount, shipping fee and total, as CreateOrder would charge them.
+func (s *OrderService) QuoteShipping(ctx context.Context, req *dto.ShippingQuoteRequest) (*dto.ShippingQuoteResponse, *common.Error) {
+	priced, err := s.priceItems(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	charges, err := s.computeCharges(ctx, priced, req.VoucherCode, req.Province)
+	if err != nil {
 		return nil, err
 	}
 
-	// 4. Generate Zalo Params
-	// Parameters: amount, desc, item, extradata, method
-	amount := order.TotalAmount.IntPart()
-	desc := orderID
+	return &dto.ShippingQuoteResponse{
+		RuleID:                charges.shippingRule.ID,
+		RuleName:              charges.shippingRule.Name,
+		Weight:                priced.weight,
+		Subtotal:              priced.subtotal.IntPart(),
+		DiscountAmount:        charges.discount.IntPart(),
+		ShippingFee:           charges.shippingFee.IntPart(),
+		TotalAmount:           priced.subtotal.Sub(charges.discount).Add(charges.shippingFee).IntPart(),
+		FreeShippingThreshold: charges.shippingRule.FreeShippingThreshold,
+	}, nil
+}
 
-	// Item: JSON string of items (simplified)
-	type zaloItem struct {
-		ID       string `json:"id"`
-		Amount   int64  `json:"amount"`
-		Name     string `json:"name"`
-		Quantity int    `json:"quantity"`
+// resolveVariant returns the variant an order item refers to. Products that
+// have variants must be ordered through one of them, since stock is tracked
+// per variant.
+func resolveVariant(ctx context.Context, product *model.Product, variantID *uint) (*model.ProductVariant, *common.Error) {
+	if variantID == nil {
+		if len(product.Variants) > 0 {
+			return nil, common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("variant_id is required for product %d", product.ID))
+		}
+		return nil, nil
 	}
 
-	var items []zaloItem
-	for _, it := range order.OrderItems {
-		items = append(items, zaloItem{
-			ID:       fmt.Sprintf("%d", it.ProductSnapshot.ProductID),
-			Amount:   it.Price.IntPart(),
-			Name:     it.ProductSnapshot.Name,
-			Quantity: it.Quantity,
-		})
+	for i := range product.Variants {
+		if product.Variants[i].ID == *variantID {
+			return &product.Variants[i], nil
+		}
+	}
+	return nil, common.ErrNotFound(ctx, "Product variant", "not found")
+}
+
+// unitPrice is what one unit costs, taken from the variant when it sets its
+// own price.
+func unitPrice(product *model.Product, variant *model.ProductVariant) int64 {
+	if variant != nil && variant.Price > 0 {
+		return variant.Price
 	}
-	itemBytes, _ := json.Marshal(items)
-	itemStr := string(itemBytes)
+	return product.Price
+}
 
-	// Extradata: {"pk_order_id": order.ID}
-	extraDataMap := map[string]interface{}{
-		"pk_order_id": orderID,
+// itemWeight is the shipping weight of one unit in grams, taken from the
+// variant when it sets its own weight.
+func itemWeight(product *model.Product, variant *model.ProductVariant) int64 {
+	if variant != nil && variant.Weight > 0 {
+		return variant.Weight
 	}
-	extraDataBytes, _ := json.Marshal(extraDataMap)
-	extraDataStr := string(extraDataBytes)
+	return product.Weight
+}
 
-	methodMap := map[string]interface{}{
-		"id":       req.Payment.Method,
-		"isCustom": false,
+// ListOrders lists orders for staff, filtered by query and sorted by the
+// pagination's sort key, newest first by default.
+func (s *OrderService) ListOrders(ctx context.Context, pagination *dto.PaginationRequest, query *dto.OrderListQuery) ([]*model.Order, int64, *common.Error) {
+	filter, err := orderFilterFromQuery(ctx, query)
+	if err != nil {
+		return nil, 0, err
 	}
-	methodBytes, _ := json.Marshal(methodMap)
-	methodStr := string(methodBytes)
+	filter.SortBy = pagination.SortBy
+	filter.SortDesc = strings.ToLower(pagination.Order) != "asc"
 
-	// MAC Generation: sort keys -> key=value -> join & -> hmac
-	// Keys: amount, desc, extradata, item, method
-	// Note: value should be stringified if object, but here we prepared strings.
-	// doc: "Dữ liệu extradata và method phải có kiểu dữ liệu JSON String"
+	offset := (pagination.Page - 1) * pagination.Size
+	return s.orderRepository.ListOrders(ctx, filter, offset, pagination.Size)
+}
 
-	// Manual construction to ensure order
-	// Sorted keys: amount, desc, extradata, item, method
-	dataMac := fmt.Sprintf("amount=%d&desc=%s&extradata=%s&item=%s&method=%s",
-		amount, desc, extraDataStr, itemStr, methodStr)
-	log.Debug(ctx, "dataMac: %s", dataMac)
+func orderFilterFromQuery(ctx context.Context, query *dto.OrderListQuery) (*repositories.OrderFilter, *common.Error) {
+	filter := &repositories.OrderFilter{
+		PaymentMethod: query.PaymentMethod,
+		Search:        query.Q,
+	}
 
-	mac := utils.ComputeHmac256(dataMac, s.cfg.ZaloAppPrivateKey)
-	log.Debug(ctx, "mac: %s", mac)
+	for _, raw := range strings.Split(query.Status, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		status := model.OrderStatus(raw)
+		if !status.IsValid() {
+			return nil, common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("unknown order status %q", raw))
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
 
-	zaloParams := &dto.ZaloOrderParams{
-		Amount:    amount,
-		Desc:      desc,
-		Item:      itemStr,
-		Extradata: extraDataStr,
-		Method:    methodStr,
-		Mac:       mac,
+	var err error
+	if filter.CreatedFrom, err = parseDateBound(query.CreatedFrom, false); err != nil {
+		return nil, common.ErrBadRequest(ctx).SetDetail("created_from: " + err.Error())
+	}
+	if filter.CreatedTo, err = parseDateBound(query.CreatedTo, true); err != nil {
+		return nil, common.ErrBadRequest(ctx).SetDetail("created_to: " + err.Error())
 	}
 
-	return &dto.CreateOrderResponse{
-		Order:      order,
-		ZaloParams: zaloParams,
-		MAC:        mac,
-	}, nil
+	if query.MinTotal != nil {
+		minTotal := decimal.NewFromInt(*query.MinTotal)
+		filter.MinTotal = &minTotal
+	}
+	if query.MaxTotal != nil {
+		maxTotal := decimal.NewFromInt(*query.MaxTotal)
+		filter.MaxTotal = &maxTotal
+	}
+	if filter.MinTotal != nil && filter.MaxTotal != nil && filter.MinTotal.GreaterThan(*filter.MaxTotal) {
+		return nil, common.ErrBadRequest(ctx).SetDetail("min_total cannot exceed max_total")
+	}
+
+	return filter, nil
 }
 
-func (s *OrderService) ListOrders(ctx context.Context, page int, size int) ([]*model.Order, int64, *common.Error) {
-	offset := (page - 1) * size
-	return s.orderRepository.ListOrders(ctx, offset, size)
+// parseDateBound parses an RFC 3339 time or a YYYY-MM-DD date in local time.
+// A date used as an upper bound moves to the start of the next day, so the
+// exclusive bound still covers the whole day.
+func parseDateBound(raw string, upper bool) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("expected RFC 3339 or YYYY-MM-DD, got %q", raw)
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
 }
 
 func (s *OrderService) GetOrder(ctx context.Context, id string) (*model.Order, *common.Error) {
 	return s.orderRepository.GetOrder(ctx, id)
 }
 
-func (s *OrderService) UpdateOrder(ctx context.Context, req *dto.UpdateOrderRequest) *common.Error {
+// ListCustomerOrders lists the orders of the Zalo user in ctx, matched by user
+// ID or by the phone number the app shared.
+func (s *OrderService) ListCustomerOrders(ctx context.Context, page int, size int, status *model.OrderStatus) ([]*model.Order, int64, *common.Error) {
+	user := common.GetZaloUser(ctx)
+	if user == nil {
+		return nil, 0, common.ErrUnauthorized(ctx)
+	}
+
+	offset := (page - 1) * size
+	return s.orderRepository.ListCustomerOrders(ctx, user.ID, utils.PhoneVariants(user.Phone), status, offset, size)
+}
+
+// GetCustomerOrder returns one order of the Zalo user in ctx. Orders of other
+// customers are reported as not found.
+func (s *OrderService) GetCustomerOrder(ctx context.Context, id string) (*model.Order, *common.Error) {
+	user := common.GetZaloUser(ctx)
+	if user == nil {
+		return nil, common.ErrUnauthorized(ctx)
+	}
+
+	return s.orderRepository.GetCustomerOrder(ctx, id, user.ID, utils.PhoneVariants(user.Phone))
+}
+
+// GetOrderVietQR returns the VietQR for paying what is still due on a bank
+// transfer order.
+func (s *OrderService) GetOrderVietQR(ctx context.Context, id string) (*dto.VietQRResponse, *common.Error) {
+	order, err := s.orderRepository.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.paymentService.BuildVietQR(ctx, order)
+}
+
+// GetCustomerOrderVietQR is GetOrderVietQR for an order of the Zalo user in
+// ctx.
+func (s *OrderService) GetCustomerOrderVietQR(ctx context.Context, id string) (*dto.VietQRResponse, *common.Error) {
+	order, err := s.GetCustomerOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.paymentService.BuildVietQR(ctx, order)
+}
+
+func (s *OrderService) GetOrderTimeline(ctx context.Context, id string) ([]*model.OrderEvent, *common.Error) {
+	if _, err := s.orderRepository.GetOrderByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.orderRepository.ListOrderEvents(ctx, id)
+}
+
+// CancelCustomerOrder cancels an order of the Zalo user in ctx. Customers may
+// only cancel while the order is still waiting for payment or confirmation.
+func (s *OrderService) CancelCustomerOrder(ctx context.Context, id string, reason string) (*model.Order, *common.Error) {
+	user := common.GetZaloUser(ctx)
+	if user == nil {
+		return nil, common.ErrUnauthorized(ctx)
+	}
+
+	order, err := s.orderRepository.GetCustomerOrder(ctx, id, user.ID, utils.PhoneVariants(user.Phone))
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != model.OrderStatusPaying && order.Status != model.OrderStatusPending {
+		return nil, common.ErrConflict(ctx, "Order", fmt.Sprintf("cannot be cancelled once %s", order.Status))
+	}
+
+	return order, s.cancelOrder(ctx, order, reason, &model.OrderEvent{
+		Source: model.OrderEventSourceCustomerAPI,
+		Actor:  user.ID,
+	})
+}
+
+// CancelOrder cancels an order on behalf of staff, which the order lifecycle
+// allows until the order is shipped.
+func (s *OrderService) CancelOrder(ctx context.Context, id string, reason string, actor string) (*model.Order, *common.Error) {
+	order, err := s.orderRepository.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, s.cancelOrder(ctx, order, reason, &model.OrderEvent{
+		Source: model.OrderEventSourceAdminAPI,
+		Actor:  actor,
+	})
+}
+
+// cancelOrder moves the order to cancelled, which returns its reserved stock,
+// and reports the failed order to Zalo. The cancellation stands even when Zalo
+// cannot be reached.
+func (s *OrderService) cancelOrder(ctx context.Context, order *model.Order, reason string, event *model.OrderEvent) *common.Error {
+	order.CancelReason = &reason
+	event.Note = reason
+	if err := changeOrderStatus(ctx, s.orderRepository, order, model.OrderStatusCancelled, event, "cancel_reason"); err != nil {
+		order.CancelReason = nil
+		return err
+	}
+
+	if err := s.paymentProviders.For(order.PaymentMethod).ReportResult(ctx, order, payment.ResultCodeFailed); err != nil && !errors.Is(err, errResultNotSupported) {
+		log.Error(ctx, "cancelOrder: failed to report order %s to Zalo: %v", order.ID, err)
+	}
+	return nil
+}
+
+// ConfirmCodDelivery records how delivery of a COD order ended: the cash was
+// collected, which completes the order, or delivery failed, which fails it
+// and returns its stock. The outcome is recorded as the COD payment attempt
+// and reported to Zalo in the background, retrying while Zalo is unreachable.
+func (s *OrderService) ConfirmCodDelivery(ctx context.Context, id string, req *dto.ConfirmCodRequest, actor string) (*model.Order, *common.Error) {
+	order, err := s.orderRepository.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if PaymentMethod(payment.BaseMethod(order.PaymentMethod)) != PaymentMethodCod {
+		return nil, common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("order %s is not a COD order", order.ID))
+	}
+
+	next, attemptStatus, resultCode := model.OrderStatusCompleted, model.PaymentAttemptSucceeded, payment.ResultCodeSuccess
+	if req.Result == dto.CodResultFailed {
+		next, attemptStatus, resultCode = model.OrderStatusFailed, model.PaymentAttemptFailed, payment.ResultCodeFailed
+	}
+
+	attempt := &model.Payment{
+		Method: order.PaymentMethod,
+		Amount: order.TotalAmount,
+		Status: attemptStatus,
+	}
+	if req.Reference != "" {
+		attempt.TransactionID = &req.Reference
+	}
+	report, err := s.paymentService.CodResultReportJob(ctx, order.ID, resultCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orderRepository.RecordCodDelivery(ctx, order, next, attempt, &model.OrderEvent{
+		Source:    model.OrderEventSourceAdminAPI,
+		Actor:     actor,
+		Reference: req.Reference,
+		Note:      req.Note,
+	}, report); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// RefundOrder returns all or part of a paid order's total to the customer.
+// Once the refunds cover the full total the order moves to refunded and a job
+// reports it to Zalo. Zalo only takes the refund of a whole order, so partial
+// refunds are reported along with the one that completes it.
+func (s *OrderService) RefundOrder(ctx context.Context, id string, req *dto.CreateRefundRequest, actor string) (*model.Order, *model.Refund, *common.Error) {
+	order, err := s.orderRepository.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paid, err := isOrderPaid(ctx, s.orderRepository, order)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !paid {
+		return nil, nil, common.ErrConflict(ctx, "Order", "has not been paid")
+	}
+
+	refund := &model.Refund{
+		OrderID:   order.ID,
+		Reason:    req.Reason,
+		Reference: req.Reference,
+		Actor:     actor,
+	}
+	if req.Amount != nil {
+		if !req.Amount.IsPositive() {
+			return nil, nil, common.ErrBadRequest(ctx).SetDetail("amount must be positive")
+		}
+		refund.Amount = *req.Amount
+	}
+
+	report, err := s.paymentService.RefundReportJob(ctx, order.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	order, err = s.orderRepository.CreateRefund(ctx, refund, &model.OrderEvent{
+		Source:    model.OrderEventSourceAdminAPI,
+		Actor:     actor,
+		Reference: req.Reference,
+		Note:      req.Reason,
+	}, report)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return order, refund, nil
+}
+
+func (s *OrderService) ListRefunds(ctx context.Context, id string) ([]*model.Refund, *common.Error) {
+	if _, err := s.orderRepository.GetOrderByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.orderRepository.ListRefunds(ctx, id)
+}
+
+func (s *OrderService) UpdateOrder(ctx context.Context, req *dto.UpdateOrderRequest, actor string) *common.Error {
 
 	id := req.OrderID
 
@@ -204,17 +576,43 @@ func (s *OrderService) UpdateOrder(ctx context.Context, req *dto.UpdateOrderRequ
 	}
 
 	log.Debug(ctx, "UpdateOrder: order %s status checked", order.ID)
+	var columns []string
 	if req.ZaloOrderID != nil {
 		log.Debug(ctx, "UpdateOrder: order %s zalo order id checked", order.ID)
 		order.ZaloOrderID = req.ZaloOrderID
+		columns = append(columns, "zalo_order_id")
 	}
 	if req.Status != nil {
 		log.Debug(ctx, "UpdateOrder: order %s status checked", order.ID)
+		if !req.Status.IsValid() {
+			return common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("unknown order status %q", *req.Status))
+		}
+		if order.Status != *req.Status && !order.Status.CanTransitionTo(*req.Status) {
+			return common.ErrInvalidTransition(ctx, "Order", string(order.Status), string(*req.Status))
+		}
+		if order.Status != *req.Status && *req.Status == model.OrderStatusRefunded {
+			paid, err := isOrderPaid(ctx, s.orderRepository, order)
+			if err != nil {
+				return err
+			}
+			if !paid {
+				return common.ErrConflict(ctx, "Order", "has not been paid")
+			}
+		}
 		order.Status = *req.Status
+		columns = append(columns, "status")
 	}
 	if req.TransactionID != nil {
 		log.Debug(ctx, "UpdateOrder: order %s transaction id checked", order.ID)
 		order.TransactionID = req.TransactionID
+		columns = append(columns, "transaction_id")
+	}
+	event := &model.OrderEvent{
+		Source: model.OrderEventSourceAdminAPI,
+		Actor:  actor,
+	}
+	if req.TransactionID != nil {
+		event.Reference = *req.TransactionID
 	}
-	return s.orderRepository.UpdateOrder(ctx, order)
+	return s.orderRepository.UpdateOrder(ctx, order, event, columns...)
 }
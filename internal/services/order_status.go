@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/client/zalo/payment"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/repositories"
+)
+
+// changeOrderStatus moves the order to next and persists it along with the
+// other columns the caller changed, recording the change with event's source,
+// actor and reference. Moves the order lifecycle does not allow are rejected;
+// setting the current status again is a no-op.
+func changeOrderStatus(ctx context.Context, orderRepository *repositories.OrderRepository, order *model.Order, next model.OrderStatus, event *model.OrderEvent, columns ...string) *common.Error {
+	if order.Status == next {
+		return nil
+	}
+	if !order.Status.CanTransitionTo(next) {
+		return common.ErrInvalidTransition(ctx, "Order", string(order.Status), string(next))
+	}
+
+	previous := order.Status
+	order.Status = next
+	if err := orderRepository.UpdateOrder(ctx, order, event, append([]string{"status"}, columns...)...); err != nil {
+		order.Status = previous
+		return err
+	}
+	return nil
+}
+
+// settleOrderPayment applies a payment result reported by Zalo. The result is
+// recorded as a payment attempt, defaulting to the order total when Zalo gave
+// no amount, in the same transaction as the status change it brings; see
+// OrderRepository.SettleOrderPayment.
+func settleOrderPayment(ctx context.Context, orderRepository *repositories.OrderRepository, order *model.Order, attempt *model.Payment, event *model.OrderEvent) *common.Error {
+	if !attempt.Amount.IsPositive() {
+		attempt.Amount = order.TotalAmount
+	}
+	return orderRepository.SettleOrderPayment(ctx, order, attempt, event)
+}
+
+// isOrderPaid reports whether the customer has paid for the order: COD orders
+// once delivered, every other method once the payment moved it out of paying.
+func isOrderPaid(ctx context.Context, orderRepository *repositories.OrderRepository, order *model.Order) (bool, *common.Error) {
+	if PaymentMethod(payment.BaseMethod(order.PaymentMethod)) == PaymentMethodCod {
+		return order.Status == model.OrderStatusCompleted || order.Status == model.OrderStatusRefunded, nil
+	}
+	return orderRepository.HasTransition(ctx, order.ID, model.OrderStatusPaying, model.OrderStatusPending)
+}
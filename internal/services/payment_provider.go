@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/client/zalo/payment"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+)
+
+var (
+	// errResultRejected means the provider answered and refused a result.
+	errResultRejected = errors.New("provider rejected the result")
+	// errResultNotSupported means nothing was sent, as the provider takes no
+	// result for the order: its method reports none, or the order was never
+	// linked to the provider.
+	errResultNotSupported = errors.New("provider takes no result for the order")
+)
+
+// PaymentProvider is one way for customers to pay for an order. Providers
+// are registered with fx in the payment_providers group and looked up by the
+// payment method of the order, so a new method only needs a provider.
+type PaymentProvider interface {
+	// Method is the payment method the provider handles.
+	Method() PaymentMethod
+	// InitialStatus is the status an order paid this way starts in.
+	InitialStatus() model.OrderStatus
+	// Initiate prepares payment of a newly created order, returning what the
+	// Mini App needs to pay for it.
+	Initiate(ctx context.Context, order *model.Order) (*PaymentInstructions, *common.Error)
+	// VerifyCallback reports whether a callback request claiming to come from
+	// the provider carries a valid signature.
+	VerifyCallback(ctx context.Context, callback any) bool
+	// QueryStatus asks the provider how payment of the order stands.
+	QueryStatus(ctx context.Context, order *model.Order) (*PaymentStatusResult, error)
+	// ReportResult tells the provider the outcome of the order. An error
+	// wrapping errResultRejected means the provider refused the result, and
+	// one wrapping errResultNotSupported that nothing was sent.
+	ReportResult(ctx context.Context, order *model.Order, resultCode int) error
+	// Refund tells the provider the order was refunded in full, with the
+	// same errors as ReportResult.
+	Refund(ctx context.Context, order *model.Order) error
+}
+
+// PaymentInstructions is how a new order is to be paid.
+type PaymentInstructions struct {
+	ZaloParams *dto.ZaloOrderParams
+	VietQR     *dto.VietQRResponse
+}
+
+// PaymentStatusResult is the payment status a provider reports for an order.
+// Attempt is only set once the provider has a final result.
+type PaymentStatusResult struct {
+	Final   bool
+	Attempt *model.Payment
+}
+
+// PaymentProviders finds the provider of a payment method.
+type PaymentProviders struct {
+	providers map[PaymentMethod]PaymentProvider
+	checkout  *ZaloCheckoutProvider
+}
+
+// NewPaymentProviders indexes providers by method. Methods without a provider
+// of their own, such as the e-wallets, are paid through Zalo Checkout.
+func NewPaymentProviders(providers []PaymentProvider, checkout *ZaloCheckoutProvider) *PaymentProviders {
+	p := &PaymentProviders{
+		providers: make(map[PaymentMethod]PaymentProvider, len(providers)),
+		checkout:  checkout,
+	}
+	for _, provider := range providers {
+		p.providers[provider.Method()] = provider
+	}
+	return p
+}
+
+// For returns the provider of method, which may be a sandbox method ID.
+func (p *PaymentProviders) For(method string) PaymentProvider {
+	if provider, ok := p.providers[PaymentMethod(payment.BaseMethod(method))]; ok {
+		return provider
+	}
+	return p.checkout
+}
+
+// Supports reports whether customers can pay with method, which may be a
+// sandbox method ID: it has a provider of its own or Zalo Checkout takes it.
+func (p *PaymentProviders) Supports(method string) bool {
+	base := PaymentMethod(payment.BaseMethod(method))
+	if _, ok := p.providers[base]; ok {
+		return true
+	}
+	return checkoutMethods[base]
+}
@@ -1,13 +1,13 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/config"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
@@ -17,142 +17,362 @@ import (
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/repositories"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+	"github.com/shopspring/decimal"
 )
 
 type PaymentMethod string
 
 const (
-	PaymentMethodBank PaymentMethod = "BANK"
-	PaymentMethodCod  PaymentMethod = "COD"
+	PaymentMethodBank    PaymentMethod = "BANK"
+	PaymentMethodCod     PaymentMethod = "COD"
+	PaymentMethodZaloPay PaymentMethod = "ZALOPAY"
+	PaymentMethodMomo    PaymentMethod = "MOMO"
+	PaymentMethodVnPay   PaymentMethod = "VNPAY"
 )
 
 type PaymentService struct {
-	orderRepository   *repositories.OrderRepository
-	zaloPaymentClient *payment.ZaloPaymentClient
-	cfg               *config.Config
+	orderRepository           *repositories.OrderRepository
+	bankTransactionRepository *repositories.BankTransactionRepository
+	zaloPaymentClient         *payment.ZaloPaymentClient
+	paymentProviders          *PaymentProviders
+	jobQueue                  *JobQueue
+	cfg                       *config.Config
 }
 
-func NewPaymentService(orderRepo *repositories.OrderRepository, zaloPaymentClient *payment.ZaloPaymentClient, cfg *config.Config) *PaymentService {
-	return &PaymentService{
-		orderRepository:   orderRepo,
-		zaloPaymentClient: zaloPaymentClient,
-		cfg:               cfg,
+func NewPaymentService(orderRepo *repositories.OrderRepository, bankTransactionRepo *repositories.BankTransactionRepository, zaloPaymentClient *payment.ZaloPaymentClient, paymentProviders *PaymentProviders, jobQueue *JobQueue, cfg *config.Config) *PaymentService {
+	s := &PaymentService{
+		orderRepository:           orderRepo,
+		bankTransactionRepository: bankTransactionRepo,
+		zaloPaymentClient:         zaloPaymentClient,
+		paymentProviders:          paymentProviders,
+		jobQueue:                  jobQueue,
+		cfg:                       cfg,
 	}
+	return s
 }
 
-func (s *PaymentService) ProcessNotifyCallback(ctx context.Context, req *dto.NofityCallbackRequest) (*dto.NofityCallbackResponse, *common.Error) {
+// JobHandlers returns the handlers of the background jobs the service queues.
+func (s *PaymentService) JobHandlers() JobHandlers {
+	return JobHandlers{
+		JobTypeZaloOrderStatusCheck: s.checkZaloOrderStatus,
+		JobTypeZaloCodResultReport:  s.reportCodResult,
+		JobTypeZaloRefundReport:     s.reportRefund,
+	}
+}
+
+// JobTypeZaloCodResultReport reports the outcome of a COD delivery to Zalo.
+const JobTypeZaloCodResultReport = "zalo_cod_result_report"
+
+type zaloCodResultReportPayload struct {
+	OrderID    string `json:"order_id"`
+	ResultCode int    `json:"result_code"`
+}
+
+// CodResultReportJob builds the job reporting a COD delivery outcome to Zalo,
+// retried until Zalo can be reached, for saving with the outcome.
+func (s *PaymentService) CodResultReportJob(ctx context.Context, orderID string, resultCode int) (*model.Job, *common.Error) {
+	return NewJob(ctx, JobTypeZaloCodResultReport, &zaloCodResultReportPayload{
+		OrderID:    orderID,
+		ResultCode: resultCode,
+	}, time.Now())
+}
+
+// reportCodResult runs a JobTypeZaloCodResultReport job. Only transport
+// errors are retried; a result Zalo refused will be refused again.
+func (s *PaymentService) reportCodResult(ctx context.Context, job *model.Job) error {
+	var payload zaloCodResultReportPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return PermanentJobError(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	order, errSvc := s.orderRepository.GetOrderByID(ctx, payload.OrderID)
+	if errSvc != nil {
+		if errSvc.GetCode() == common.ErrorCodeNotFound {
+			return PermanentJobError(errSvc)
+		}
+		return errSvc
+	}
+
+	err := s.paymentProviders.For(order.PaymentMethod).ReportResult(ctx, order, payload.ResultCode)
+	if errors.Is(err, errResultNotSupported) {
+		log.Info(ctx, "reportCodResult: nothing to report for order %s: %v", order.ID, err)
+		return nil
+	}
+	if errors.Is(err, errResultRejected) {
+		return PermanentJobError(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info(ctx, "reportCodResult: reported result %d of order %s to Zalo", payload.ResultCode, order.ID)
+	return nil
+}
+
+// JobTypeZaloRefundReport reports a fully refunded order to Zalo.
+const JobTypeZaloRefundReport = "zalo_refund_report"
+
+type zaloRefundReportPayload struct {
+	OrderID string `json:"order_id"`
+}
+
+// RefundReportJob builds the job reporting the refund of the order to Zalo,
+// for saving with the refund that completes it.
+func (s *PaymentService) RefundReportJob(ctx context.Context, orderID string) (*model.Job, *common.Error) {
+	return NewJob(ctx, JobTypeZaloRefundReport, &zaloRefundReportPayload{OrderID: orderID}, time.Now())
+}
+
+// reportRefund runs a JobTypeZaloRefundReport job. Zalo only takes the refund
+// of a whole order, so once it has, every refund of the order is marked
+// reported, partial ones included. A method Zalo takes no result for leaves
+// the refunds unreported.
+func (s *PaymentService) reportRefund(ctx context.Context, job *model.Job) error {
+	var payload zaloRefundReportPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return PermanentJobError(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	order, errSvc := s.orderRepository.GetOrderByID(ctx, payload.OrderID)
+	if errSvc != nil {
+		if errSvc.GetCode() == common.ErrorCodeNotFound {
+			return PermanentJobError(errSvc)
+		}
+		return errSvc
+	}
 
-	// 1. Verify Message Authentication Code (HMAC-SHA256)
-	// data = 'appId={appId}&orderId={orderId}&method={method}'
-	dataForMac := fmt.Sprintf("appId=%s&orderId=%s&method=%s",
-		req.Data.AppID, req.Data.OrderID, req.Data.Method)
+	err := s.paymentProviders.For(order.PaymentMethod).Refund(ctx, order)
+	if errors.Is(err, errResultNotSupported) {
+		log.Info(ctx, "reportRefund: nothing to report for order %s: %v", order.ID, err)
+		return nil
+	}
+	if errors.Is(err, errResultRejected) {
+		return PermanentJobError(err)
+	}
+	if err != nil {
+		return err
+	}
 
-	mac := utils.ComputeHmac256(dataForMac, s.cfg.ZaloAppPrivateKey)
-	if mac != req.Mac {
-		// Log for debugging
-		log.Debug(ctx, fmt.Sprintf("dataForMac: %s\n", dataForMac))
-		log.Error(ctx, fmt.Sprintf("Invalid MAC: calculated %s, received %s\n", mac, req.Mac))
+	if errSvc := s.orderRepository.MarkRefundsReported(ctx, order.ID); errSvc != nil {
+		return errSvc
+	}
+	log.Info(ctx, "reportRefund: reported refund of order %s to Zalo", order.ID)
+	return nil
+}
+
+func (s *PaymentService) ProcessNotifyCallback(ctx context.Context, req *dto.NofityCallbackRequest) (*dto.NofityCallbackResponse, *common.Error) {
+
+	// 1. Verify the signature with the provider of the chosen method
+	if !s.paymentProviders.For(req.Data.Method).VerifyCallback(ctx, req) {
 		return &dto.NofityCallbackResponse{
 			ReturnCode:    -1,
 			ReturnMessage: "mac not equal",
 		}, nil
 	}
 
+	// 2. Find our order in the extradata Zalo keeps on its order. The MAC
+	// does not cover the notification's own extradata, so that is not trusted
+	orderID, err := s.orderIDForZaloOrder(ctx, req.Data.OrderID)
+	if err != nil {
+		log.Error(ctx, "ProcessNotifyCallback: %v", err)
+		return &dto.NofityCallbackResponse{
+			ReturnCode:    -1,
+			ReturnMessage: "pk_order_id not found in extradata",
+		}, nil
+	}
+
+	// 3. Link the Zalo order and method. A method that needs no upfront
+	// payment, like COD, moves a paying order to pending; any other is checked
+	// with Zalo until it settles. A repeated notification is a no-op
+	check, errSvc := orderStatusCheckJob(ctx, orderID, req.Data.OrderID)
+	if errSvc != nil {
+		return nil, errSvc
+	}
+	_, _, errSvc = s.orderRepository.LinkZaloOrder(ctx, orderID, req.Data.OrderID, req.Data.Method,
+		s.paymentProviders.For(req.Data.Method).InitialStatus(), &model.OrderEvent{
+			Source:    model.OrderEventSourceZaloNotify,
+			Actor:     "zalo",
+			Reference: req.Data.OrderID,
+			Note:      fmt.Sprintf("customer chose %s", req.Data.Method),
+		}, check)
+	if errSvc != nil {
+		switch errSvc.GetCode() {
+		case common.ErrorCodeNotFound:
+			return &dto.NofityCallbackResponse{
+				ReturnCode:    -1,
+				ReturnMessage: "order not found",
+			}, nil
+		case common.ErrorCodeConflict:
+			log.Warn(ctx, "ProcessNotifyCallback: not linking order %s to Zalo order %s: %s", orderID, req.Data.OrderID, errSvc.GetDetail())
+			return &dto.NofityCallbackResponse{
+				ReturnCode:    -1,
+				ReturnMessage: "order is not waiting for payment",
+			}, nil
+		}
+		return nil, errSvc
+	}
+
 	return &dto.NofityCallbackResponse{
 		ReturnCode:    1,
 		ReturnMessage: "success",
 	}, nil
 }
 
-func (s *PaymentService) deferredCheckOrderStatus(zaloOrderID string) {
+// orderIDFromExtradata reads our order ID from the extradata we gave Zalo at
+// checkout. Zalo may return it URL-encoded.
+func orderIDFromExtradata(extradata string) (string, error) {
+	decoded, err := url.QueryUnescape(extradata)
+	if err != nil {
+		return "", fmt.Errorf("failed to unescape extradata: %w", err)
+	}
 
-	ctx := context.Background()
-	log.Debug(ctx, fmt.Sprintf("Starting deferred check for Zalo Order ID: %s\n", zaloOrderID))
+	var extraDataMap map[string]interface{}
+	if err := json.Unmarshal([]byte(decoded), &extraDataMap); err != nil {
+		return "", fmt.Errorf("failed to parse extradata: %w", err)
+	}
 
-	// Get Order Status from Zalo
-	orderStatus, err := s.zaloPaymentClient.GetOrderStatus(ctx, s.cfg, zaloOrderID)
-	if err != nil {
-		log.Error(ctx, fmt.Sprintf("deferredCheckOrderStatus: failed to get status for %s: %v\n", zaloOrderID, err))
-		return
+	orderID, ok := extraDataMap["pk_order_id"].(string)
+	if !ok || orderID == "" {
+		return "", fmt.Errorf("pk_order_id not found in extradata %q", extradata)
 	}
+	return orderID, nil
+}
+
+// JobTypeZaloOrderStatusCheck asks Zalo for the status of a paid order and
+// settles it once Zalo reports a final result.
+const JobTypeZaloOrderStatusCheck = "zalo_order_status_check"
+
+// zaloStatusCheckDelay gives Zalo time to process a payment before the first
+// status check.
+const zaloStatusCheckDelay = 5 * time.Minute
 
-	log.Debug(ctx, fmt.Sprintf("deferredCheckOrderStatus: order %s status: %v\n", zaloOrderID, orderStatus))
+var errZaloOrderProcessing = errors.New("order still processing at zalo")
 
-	// Only proceed if payment is successful
-	if orderStatus.Err != 0 {
-		log.Error(ctx, fmt.Sprintf("deferredCheckOrderStatus: order %s error. Error: %d\n",
-			zaloOrderID, orderStatus.Err))
-		return
+// zaloOrderStatusCheckPayload names the order to check. Jobs queued before
+// OrderID was added only carry the Zalo order, whose extradata names ours.
+type zaloOrderStatusCheckPayload struct {
+	OrderID     string `json:"order_id,omitempty"`
+	ZaloOrderID string `json:"zalo_order_id"`
+}
+
+func (s *PaymentService) scheduleOrderStatusCheck(ctx context.Context, order *model.Order) *common.Error {
+	payload := &zaloOrderStatusCheckPayload{OrderID: order.ID}
+	if order.ZaloOrderID != nil {
+		payload.ZaloOrderID = *order.ZaloOrderID
 	}
+	return s.jobQueue.Enqueue(ctx, JobTypeZaloOrderStatusCheck, payload, time.Now().Add(zaloStatusCheckDelay))
+}
 
-	currentStatus := model.OrderStatusPending
-	if orderStatus.Data.ReturnCode == 1 {
-		currentStatus = model.OrderStatusCompleted
+// orderStatusCheckJob builds the status check of the order's Zalo order like
+// scheduleOrderStatusCheck, for saving with the change that calls for it.
+func orderStatusCheckJob(ctx context.Context, orderID, zaloOrderID string) (*model.Job, *common.Error) {
+	return NewJob(ctx, JobTypeZaloOrderStatusCheck, &zaloOrderStatusCheckPayload{
+		OrderID:     orderID,
+		ZaloOrderID: zaloOrderID,
+	}, time.Now().Add(zaloStatusCheckDelay))
+}
+
+// checkZaloOrderStatus runs a JobTypeZaloOrderStatusCheck job, asking the
+// provider of the order's method for its status. It fails while there is no
+// final result, so the queue checks again later.
+func (s *PaymentService) checkZaloOrderStatus(ctx context.Context, job *model.Job) error {
+	var payload zaloOrderStatusCheckPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return PermanentJobError(fmt.Errorf("invalid payload: %w", err))
 	}
-	if orderStatus.Data.ReturnCode == 0 {
-		currentStatus = model.OrderStatusProcessing
+	log.Debug(ctx, "checkZaloOrderStatus: checking Zalo Order ID %s, attempt %d", payload.ZaloOrderID, job.Attempts)
+
+	orderID := payload.OrderID
+	if orderID == "" {
+		var err error
+		if orderID, err = s.orderIDForZaloOrder(ctx, payload.ZaloOrderID); err != nil {
+			return err
+		}
 	}
-	if orderStatus.Data.ReturnCode == -1 {
-		currentStatus = model.OrderStatusFailed
+
+	order, errSvc := s.orderRepository.GetOrder(ctx, orderID)
+	if errSvc != nil {
+		return errSvc
 	}
 
-	// Parse ExtraData to get internal Order ID
-	decodedExtradata, err := url.QueryUnescape(orderStatus.Data.Extradata)
+	status, err := s.paymentProviders.For(order.PaymentMethod).QueryStatus(ctx, order)
 	if err != nil {
-		log.Error(ctx, fmt.Sprintf("deferredCheckOrderStatus: failed to unescape extradata for %s: %v\n", zaloOrderID, err))
-		return
+		return err
+	}
+	if !status.Final {
+		return errZaloOrderProcessing
 	}
 
-	var extraDataMap map[string]interface{}
-	if err := json.Unmarshal([]byte(decodedExtradata), &extraDataMap); err != nil {
-		log.Error(ctx, fmt.Sprintf("deferredCheckOrderStatus: failed to parse extradata for %s: %v\n", zaloOrderID, err))
-		return
+	reference := ""
+	if status.Attempt.TransactionID != nil {
+		reference = *status.Attempt.TransactionID
 	}
 
-	pkOrderID, ok := extraDataMap["pk_order_id"].(string)
-	if !ok {
-		log.Error(ctx, fmt.Sprintf("deferredCheckOrderStatus: pk_order_id not found in extradata for %s\n", zaloOrderID))
-		return
+	if errSvc := settleOrderPayment(ctx, s.orderRepository, order, status.Attempt, &model.OrderEvent{
+		Source:    model.OrderEventSourceReconciliation,
+		Actor:     "zalo",
+		Reference: reference,
+	}); errSvc != nil {
+		return errSvc
 	}
-	orderID := pkOrderID
 
-	// Update Order Status in DB
-	order, errSvc := s.orderRepository.GetOrder(ctx, orderID)
-	if errSvc != nil {
-		log.Error(ctx, fmt.Sprintf("deferredCheckOrderStatus: failed to get order %s: %v\n", orderID, errSvc))
-		return
+	log.Info(ctx, "checkZaloOrderStatus: order %s is now %s", orderID, order.Status)
+	return nil
+}
+
+// orderIDForZaloOrder asks Zalo for the extradata of a Zalo order and reads
+// our order ID from it.
+func (s *PaymentService) orderIDForZaloOrder(ctx context.Context, zaloOrderID string) (string, error) {
+	orderStatus, err := s.zaloPaymentClient.GetOrderStatus(ctx, s.cfg, zaloOrderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get status for %s: %w", zaloOrderID, err)
 	}
 
-	// Idempotency check
-	if order.Status == model.OrderStatusCompleted {
-		log.Error(ctx, fmt.Sprintf("deferredCheckOrderStatus: order %s already success\n", orderID))
-		return
+	orderID, err := orderIDFromExtradata(orderStatus.Data.Extradata)
+	if err != nil {
+		return "", fmt.Errorf("order %s: %w", zaloOrderID, err)
 	}
+	return orderID, nil
+}
 
-	order.Status = currentStatus
-	// Ensure TransactionID is set if missing
-	if order.TransactionID == nil || *order.TransactionID == "" {
-		transID := orderStatus.Data.TransID // Assuming TransID is available in status response
-		order.TransactionID = &transID
+// zaloPaymentResult builds the payment attempt for a result Zalo reported on
+// the order. Zalo may omit the method, in which case the order's is used.
+func zaloPaymentResult(order *model.Order, method string, amount int64, paid bool, transID, zaloOrderID, payload string) *model.Payment {
+	if method == "" {
+		method = order.PaymentMethod
+	}
+	status := model.PaymentAttemptFailed
+	if paid {
+		status = model.PaymentAttemptSucceeded
 	}
 
-	if errSvc := s.orderRepository.UpdateOrder(ctx, order); errSvc != nil {
-		log.Error(ctx, fmt.Sprintf("deferredCheckOrderStatus: failed to update order %s: %v\n", orderID, errSvc))
-		return
+	attempt := &model.Payment{
+		Method:  method,
+		Amount:  decimal.NewFromInt(amount),
+		Status:  status,
+		Payload: payload,
+	}
+	if transID != "" {
+		attempt.TransactionID = &transID
 	}
+	if zaloOrderID != "" {
+		attempt.ZaloOrderID = &zaloOrderID
+	}
+	return attempt
+}
+
+// BuildVietQR makes the VietQR for paying what is still due on a bank
+// transfer order.
+func (s *PaymentService) BuildVietQR(ctx context.Context, order *model.Order) (*dto.VietQRResponse, *common.Error) {
+	return buildVietQR(ctx, s.cfg, order)
+}
 
-	log.Info(ctx, fmt.Sprintf("deferredCheckOrderStatus: successfully updated order %s to success\n", orderID))
+func (s *PaymentService) ignoreBankTransaction(ctx context.Context, txn *model.BankTransaction, reason string) *common.Error {
+	return s.bankTransactionRepository.MarkBankTransactionIgnored(ctx, txn.ID, reason)
 }
 
 func (s *PaymentService) ProcessOrderCallback(ctx context.Context, req *dto.OrderCallbackRequest) (*dto.OrderCallbackResponse, *common.Error) {
-	// 1. Verify Message Authentication Code (HMAC-SHA256)
-	// dataForMac = "appId={appId}&amount={amount}&description={description}&orderId={orderId}&message={message}&resultCode={resultCode}&transId={transId}"
-	dataForMac := fmt.Sprintf("appId=%s&amount=%d&description=%s&orderId=%s&message=%s&resultCode=%d&transId=%s",
-		req.AppID, req.Amount, req.Description, req.OrderID, req.Message, req.ResultCode, req.TransID)
-
-	mac := utils.ComputeHmac256(dataForMac, s.cfg.ZaloAppSecret)
-	if mac != req.Mac {
-		log.Error(ctx, fmt.Sprintf("Invalid MAC: calculated %s, received %s\n", mac, req.Mac))
+	// 1. Verify the signature with the provider of the paid method
+	if !s.paymentProviders.For(req.Method).VerifyCallback(ctx, req) {
 		return &dto.OrderCallbackResponse{
 			ReturnCode:    -1,
 			ReturnMessage: "mac not equal",
@@ -186,27 +406,16 @@ func (s *PaymentService) ProcessOrderCallback(ctx context.Context, req *dto.Orde
 		}, nil
 	}
 
-	// Check if already paid
-	if order.Status == "success" {
-		// Idempotency: return success if already success
-		return &dto.OrderCallbackResponse{
-			ReturnCode:    1,
-			ReturnMessage: "success",
-		}, nil
-	}
-
-	if req.ResultCode == 1 {
-		order.Status = "success"
-	} else {
-		// Only update to failed if currently pending? or just log?
-		// For now mark as failed
-		order.Status = "failed"
-	}
-
-	// Store Zalo Trans ID if not already
-	order.TransactionID = &req.TransID
-
-	if errSvc := s.orderRepository.UpdateOrder(ctx, order); errSvc != nil {
+	// Idempotency: the attempt is matched by transaction ID, and an order that
+	// already left paying was settled earlier and keeps its status
+	raw, _ := json.Marshal(req)
+	result := zaloPaymentResult(order, req.Method, req.Amount, req.ResultCode == 1, req.TransID, req.OrderID, string(raw))
+	if errSvc := settleOrderPayment(ctx, s.orderRepository, order, result, &model.OrderEvent{
+		Source:    model.OrderEventSourceZaloCallback,
+		Actor:     "zalo",
+		Reference: req.TransID,
+		Note:      req.Message,
+	}); errSvc != nil {
 		return &dto.OrderCallbackResponse{
 			ReturnCode:    -1,
 			ReturnMessage: "database update failed",
@@ -219,55 +428,99 @@ func (s *PaymentService) ProcessOrderCallback(ctx context.Context, req *dto.Orde
 	}, nil
 }
 
+// bankTransferIn is the TransferType of money received on the account.
+const bankTransferIn = "in"
+
+// ProcessWebhookReceiver records a bank transfer in the ledger and applies it
+// to the order named in its content. Outgoing transfers and transfers that
+// name no known order are recorded as ignored and acknowledged, so the bank
+// does not retry them; a redelivered transfer is acknowledged without side
+// effects. Partial transfers add up until they cover the order total, which
+// settles the order; money beyond the total leaves the order overpaid for
+// staff to refund.
 func (s *PaymentService) ProcessWebhookReceiver(ctx context.Context, req *dto.WebhookReceiverRequest) *common.Error {
 
 	log.Debug(ctx, fmt.Sprintf("ProcessWebhookReceiver: received content: %s", req.Content))
 
-	contentParts := strings.Fields(req.Content)
-	if len(contentParts) == 0 {
-		return common.ErrBadRequest(ctx)
+	raw, _ := json.Marshal(req)
+	txn := &model.BankTransaction{
+		ProviderID:      req.ID,
+		Gateway:         req.Gateway,
+		AccountNumber:   req.AccountNumber,
+		ReferenceCode:   req.ReferenceCode,
+		TransferType:    req.TransferType,
+		Amount:          decimal.NewFromInt(req.TransferAmount),
+		Content:         req.Content,
+		TransactionDate: req.TransactionDate,
+		Payload:         string(raw),
+		Status:          model.BankTransactionStatusReceived,
 	}
-	responseOrderID := contentParts[0]
-	log.Debug(ctx, fmt.Sprintf("ProcessWebhookReceiver: parsed order ID: %s", responseOrderID))
-
-	order, errSvc := s.orderRepository.GetOrder(ctx, responseOrderID)
+	duplicate, errSvc := s.bankTransactionRepository.RecordBankTransaction(ctx, txn)
 	if errSvc != nil {
-		return common.ErrNotFound(ctx, "Order", "not found")
+		return errSvc
+	}
+	if duplicate {
+		log.Info(ctx, "ProcessWebhookReceiver: transfer %d was already %s", req.ID, txn.Status)
+		return nil
 	}
 
-	order.Status = model.OrderStatusProcessing
-
-	s.orderRepository.UpdateOrder(ctx, order)
+	if !strings.EqualFold(req.TransferType, bankTransferIn) {
+		log.Info(ctx, "ProcessWebhookReceiver: ignoring %q transfer %d", req.TransferType, req.ID)
+		return s.ignoreBankTransaction(ctx, txn, "outgoing transfer")
+	}
+	if req.TransferAmount <= 0 {
+		log.Warn(ctx, "ProcessWebhookReceiver: ignoring transfer %d with amount %d", req.ID, req.TransferAmount)
+		return s.ignoreBankTransaction(ctx, txn, "non-positive amount")
+	}
 
-	// Notify Zalo Mini App
-	resultCode := 1
-	dataForMac := fmt.Sprintf("appId=%s&orderId=%s&resultCode=%d", s.cfg.ZaloAppID, order.ID, resultCode)
-	mac := utils.ComputeHmac256(dataForMac, s.cfg.ZaloAppSecret)
+	responseOrderID := utils.ExtractOrderID(req.Content)
+	if responseOrderID == "" {
+		log.Warn(ctx, "ProcessWebhookReceiver: no order ID in content of transfer %d: %q", req.ID, req.Content)
+		return s.ignoreBankTransaction(ctx, txn, "no order ID in content")
+	}
+	log.Debug(ctx, fmt.Sprintf("ProcessWebhookReceiver: parsed order ID: %s", responseOrderID))
 
-	payload, _ := json.Marshal(map[string]interface{}{
-		"appId":      s.cfg.ZaloAppID,
-		"orderId":    order.ZaloOrderID,
-		"resultCode": resultCode,
-		"mac":        mac,
+	order, settled, errSvc := s.orderRepository.ApplyBankTransfer(ctx, txn, responseOrderID, &model.OrderEvent{
+		Source:    model.OrderEventSourceBankWebhook,
+		Actor:     req.Gateway,
+		Reference: fmt.Sprintf("%d/%s", req.ID, req.ReferenceCode),
+		Note:      fmt.Sprintf("received %d", req.TransferAmount),
 	})
-
-	zaloURL := fmt.Sprintf("https://payment-mini.zalo.me/api/transaction/%s/bank-callback-payment", s.cfg.ZaloAppID)
-	log.Debug(ctx, fmt.Sprintf("ProcessWebhookReceiver: sending to Zalo Mini App URL: %s, payload: %s", zaloURL, string(payload)))
-
-	resp, err := http.Post(zaloURL, "application/json", bytes.NewBuffer(payload))
-	if err != nil {
-		return common.ErrSystemError(ctx, "Notify Zalo Mini App failed")
+	if errSvc != nil {
+		switch errSvc.GetCode() {
+		case common.ErrorCodeNotFound:
+			log.Warn(ctx, "ProcessWebhookReceiver: transfer %d names unknown order %s", req.ID, responseOrderID)
+			return s.ignoreBankTransaction(ctx, txn, fmt.Sprintf("unknown order %s", responseOrderID))
+		case common.ErrorCodeConflict:
+			// A concurrent delivery of the same transfer got there first.
+			log.Info(ctx, "ProcessWebhookReceiver: transfer %d was already processed", req.ID)
+			return nil
+		}
+		return errSvc
 	}
 
-	defer resp.Body.Close()
+	log.Info(ctx, "ProcessWebhookReceiver: order %s received %d, paid %s of %s (%s)",
+		order.ID, req.TransferAmount, order.PaidAmount.StringFixed(0), order.TotalAmount.StringFixed(0), order.PaymentStatus)
+	if !settled {
+		return nil
+	}
 
-	log.Debug(ctx, fmt.Sprintf("Notify Zalo Mini App response status: %s", resp.Status))
-	log.Debug(ctx, fmt.Sprintf("Notify Zalo Mini App success: %s\n", order.ID))
+	// Notify Zalo Mini App. The transfer is already applied, so a bank retry
+	// would be a duplicate; a failed notification is left to the status check
+	// below.
+	if err := s.paymentProviders.For(string(PaymentMethodBank)).ReportResult(ctx, order, payment.ResultCodeSuccess); errors.Is(err, errResultNotSupported) {
+		log.Debug(ctx, "ProcessWebhookReceiver: order %s has no Zalo order to notify", order.ID)
+	} else if err != nil {
+		log.Error(ctx, "ProcessWebhookReceiver: notify Zalo Mini App failed for order %s: %v", order.ID, err)
+	} else {
+		log.Debug(ctx, "ProcessWebhookReceiver: notified Zalo Mini App for order %s", order.ID)
+	}
 
-	// 2. Check order status after 5 minutes
+	// 2. Check order status once Zalo has had time to process it
 	if order.ZaloOrderID != nil {
-		go s.deferredCheckOrderStatus(*order.ZaloOrderID)
-		log.Debug(ctx, fmt.Sprintf("ProcessWebhookReceiver: order %s status checked", order.ID))
+		if errSvc := s.scheduleOrderStatusCheck(ctx, order); errSvc != nil {
+			log.Error(ctx, "ProcessWebhookReceiver: failed to schedule status check for order %s: %v", order.ID, errSvc)
+		}
 	}
 
 	return nil
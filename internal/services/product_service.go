@@ -2,8 +2,13 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/log"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/utils"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/repositories"
 	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
@@ -12,12 +17,22 @@ import (
 type ProductService struct {
 	productRepository *repositories.ProductRepository
 	imageRepository   *repositories.ImageRepository
+	jobQueue          *JobQueue
 }
 
-func NewProductService(productRepo *repositories.ProductRepository, imageRepo *repositories.ImageRepository) *ProductService {
-	return &ProductService{
+func NewProductService(productRepo *repositories.ProductRepository, imageRepo *repositories.ImageRepository, jobQueue *JobQueue) *ProductService {
+	s := &ProductService{
 		productRepository: productRepo,
 		imageRepository:   imageRepo,
+		jobQueue:          jobQueue,
+	}
+	return s
+}
+
+// JobHandlers returns the handlers of the background jobs the service queues.
+func (s *ProductService) JobHandlers() JobHandlers {
+	return JobHandlers{
+		JobTypeProductPublication: s.applyPublicationSchedule,
 	}
 }
 
@@ -47,33 +62,198 @@ func (s *ProductService) CreateProduct(ctx context.Context, product *dto.CreateP
 	newProduct := product.ToModel()
 	newProduct.CategoryID = product.CategoryID
 
+	slug, err := s.productSlug(ctx, product.Slug, product.Name, 0)
+	if err != nil {
+		return err
+	}
+	newProduct.Slug = slug
+
+	if errPublication := newProduct.CheckPublication(time.Now()); errPublication != nil {
+		return common.ErrBadRequest(ctx).SetDetail(errPublication.Error())
+	}
+
 	if product.Variants != nil {
 		var variants []model.ProductVariant
 		for _, v := range product.Variants {
 			variants = append(variants, model.ProductVariant{
-				Name:  v.Name,
-				Price: v.Price,
-				Stock: v.Stock,
+				Name:   v.Name,
+				Price:  v.Price,
+				Stock:  v.Stock,
+				Weight: v.Weight,
 			})
 		}
 		newProduct.Variants = variants
 	}
 
-	return s.productRepository.CreateProduct(ctx, newProduct)
+	if err := s.productRepository.CreateProduct(ctx, newProduct); err != nil {
+		return err
+	}
+	s.schedulePublication(ctx, newProduct)
+
+	if tags := model.NormalizeTagNames(product.Tags); len(tags) > 0 {
+		return s.productRepository.ReplaceProductTags(ctx, newProduct, tags)
+	}
+	return nil
 }
 
+// GetProductByID loads a product on the storefront; drafts and products
+// scheduled for later are not found.
 func (s *ProductService) GetProductByID(ctx context.Context, id uint) (*model.Product, *common.Error) {
 	product, err := s.productRepository.GetProductDetailByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if !product.IsVisibleAt(time.Now()) {
+		return nil, common.ErrNotFound(ctx, "Product", "not found")
+	}
 
 	return product, nil
 }
 
-func (s *ProductService) ListProducts(ctx context.Context, page int, size int) ([]*model.Product, int64, *common.Error) {
-	offset := (page - 1) * size
-	return s.productRepository.ListProducts(ctx, offset, size)
+// PreviewProduct loads a product whether or not it is on the storefront, for
+// the admin to check drafts before they go live.
+func (s *ProductService) PreviewProduct(ctx context.Context, id uint) (*model.Product, *common.Error) {
+	return s.productRepository.GetProductDetailByID(ctx, id)
+}
+
+// GetProductBySlug finds a product on the storefront by its slug. For a slug
+// the product had before, it returns the current slug to redirect to instead.
+func (s *ProductService) GetProductBySlug(ctx context.Context, slug string) (*model.Product, string, *common.Error) {
+	now := time.Now()
+	product, err := s.productRepository.GetProductBySlug(ctx, slug)
+	if err == nil {
+		if !product.IsVisibleAt(now) {
+			return nil, "", common.ErrNotFound(ctx, "Product", "not found")
+		}
+		return product, "", nil
+	}
+	if err.GetCode() != common.ErrorCodeNotFound {
+		return nil, "", err
+	}
+
+	current, err := s.productRepository.GetRedirectedSlug(ctx, slug, now)
+	if err != nil {
+		return nil, "", err
+	}
+	return nil, current, nil
+}
+
+// maxSlugSuffix bounds the numbers tried to make a generated slug unique.
+const maxSlugSuffix = 100
+
+// productSlug returns the slug for product id, or a new product when id is 0.
+// A requested slug is normalized and must be free; otherwise one is made from
+// the name, numbered if another product has it.
+func (s *ProductService) productSlug(ctx context.Context, requested string, name string, id uint) (string, *common.Error) {
+	if requested != "" {
+		slug := utils.Slugify(requested)
+		if slug == "" {
+			return "", common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("slug %q has no letters or digits", requested))
+		}
+		taken, err := s.productRepository.IsSlugTaken(ctx, slug, id)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", common.ErrConflict(ctx, "Product slug", "already exists")
+		}
+		return slug, nil
+	}
+
+	base := utils.Slugify(name)
+	if base == "" {
+		base = "product"
+	}
+	for n := 1; n <= maxSlugSuffix; n++ {
+		slug := base
+		if n > 1 {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken, err := s.productRepository.IsSlugTaken(ctx, slug, id)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+	}
+	return "", common.ErrConflict(ctx, "Product slug", fmt.Sprintf("no free slug for %q", name))
+}
+
+// ListProducts lists a page of the catalog matching query, with the facets of
+// every matching product.
+func (s *ProductService) ListProducts(ctx context.Context, pagination *dto.PaginationRequest, query *dto.ProductListQuery) (*dto.ProductListResponse, *common.Error) {
+	return listProducts(ctx, s.productRepository, pagination, productFilterFromQuery(query))
+}
+
+// SearchProducts finds products matching the query, accepting it with or
+// without Vietnamese diacritics. Matches come by relevance unless another
+// sort is asked for.
+func (s *ProductService) SearchProducts(ctx context.Context, pagination *dto.PaginationRequest, query *dto.ProductSearchQuery) (*dto.ProductListResponse, *common.Error) {
+	filter := productFilterFromQuery(&query.ProductListQuery)
+	filter.Query = strings.TrimSpace(query.Q)
+	return listProducts(ctx, s.productRepository, pagination, filter)
+}
+
+func productFilterFromQuery(query *dto.ProductListQuery) *repositories.ProductFilter {
+	return &repositories.ProductFilter{
+		CategoryID:  query.CategoryID,
+		MinPrice:    query.MinPrice,
+		MaxPrice:    query.MaxPrice,
+		InStock:     query.InStock,
+		HasVariants: query.HasVariants,
+		Tag:         strings.ToLower(strings.TrimSpace(query.Tag)),
+	}
+}
+
+// listProducts loads the page of products matching filter, sorted as the
+// pagination asks, and the facets of the whole match.
+func listProducts(ctx context.Context, productRepository *repositories.ProductRepository, pagination *dto.PaginationRequest, filter *repositories.ProductFilter) (*dto.ProductListResponse, *common.Error) {
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return nil, common.ErrBadRequest(ctx).SetDetail("min_price must not exceed max_price")
+	}
+	now := time.Now()
+	filter.VisibleAt = &now
+	filter.SortBy = pagination.SortBy
+	filter.SortOrder = pagination.Order
+
+	offset := (pagination.Page - 1) * pagination.Size
+	products, total, err := productRepository.SearchProducts(ctx, filter, offset, pagination.Size)
+	if err != nil {
+		return nil, err
+	}
+	facets, err := productRepository.GetProductFacets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	productResponses := make([]dto.ProductResponse, 0, len(products))
+	for _, p := range products {
+		productResponses = append(productResponses, *dto.NewProductResponse(p))
+	}
+
+	return &dto.ProductListResponse{
+		PaginationResponse: dto.NewPaginationResponse(productResponses, total, *pagination),
+		Facets:             newProductFacetsResponse(facets),
+	}, nil
+}
+
+func newProductFacetsResponse(facets *repositories.ProductFacets) *dto.ProductFacetsResponse {
+	res := &dto.ProductFacetsResponse{
+		Categories:  make([]dto.CategoryFacetResponse, 0, len(facets.Categories)),
+		Tags:        make([]dto.TagFacetResponse, 0, len(facets.Tags)),
+		InStock:     facets.InStock,
+		HasVariants: facets.HasVariants,
+		MinPrice:    facets.MinPrice,
+		MaxPrice:    facets.MaxPrice,
+	}
+	for _, c := range facets.Categories {
+		res.Categories = append(res.Categories, dto.CategoryFacetResponse{CategoryID: c.CategoryID, Count: c.Count})
+	}
+	for _, t := range facets.Tags {
+		res.Tags = append(res.Tags, dto.TagFacetResponse{Tag: t.Name, Count: t.Count})
+	}
+	return res
 }
 
 func (s *ProductService) UpdateProduct(ctx context.Context, product *dto.UpdateProductRequest) *common.Error {
@@ -91,20 +271,121 @@ func (s *ProductService) UpdateProduct(ctx context.Context, product *dto.UpdateP
 	if product.Price != nil {
 		productmodel.Price = *product.Price
 	}
+	if product.Weight != nil {
+		productmodel.Weight = *product.Weight
+	}
 	if product.CategoryID != nil {
 		productmodel.CategoryID = *product.CategoryID
 	}
+	// Renaming keeps the slug, so shared links stay as they are
+	if product.Slug != nil {
+		slug, err := s.productSlug(ctx, *product.Slug, productmodel.Name, productmodel.ID)
+		if err != nil {
+			return err
+		}
+		productmodel.Slug = slug
+	}
+	if product.MetaTitle != nil {
+		productmodel.MetaTitle = product.MetaTitle
+	}
+	if product.MetaDescription != nil {
+		productmodel.MetaDescription = product.MetaDescription
+	}
+
+	if err := s.productRepository.UpdateProduct(ctx, productmodel); err != nil {
+		return err
+	}
+
+	if product.Tags != nil {
+		return s.productRepository.ReplaceProductTags(ctx, productmodel, model.NormalizeTagNames(product.Tags))
+	}
+	return nil
+}
+
+// ArchiveProduct moves a product to the trash, taking it off the storefront
+// until it is restored.
+func (s *ProductService) ArchiveProduct(ctx context.Context, id uint) *common.Error {
+	return s.productRepository.ArchiveProduct(ctx, id)
+}
+
+// RestoreProduct takes a product out of the trash.
+func (s *ProductService) RestoreProduct(ctx context.Context, id uint) (*model.Product, *common.Error) {
+	if err := s.productRepository.RestoreProduct(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.productRepository.GetProductDetailByID(ctx, id)
+}
 
-	return s.productRepository.UpdateProduct(ctx, productmodel)
+// ListHiddenProducts lists the products not on the storefront, for the admin
+// to preview.
+func (s *ProductService) ListHiddenProducts(ctx context.Context, pagination *dto.PaginationRequest) ([]*model.Product, int64, *common.Error) {
+	return s.productRepository.ListHiddenProducts(ctx, time.Now(), (pagination.Page-1)*pagination.Size, pagination.Size)
 }
 
-func (s *ProductService) DeleteProduct(ctx context.Context, id uint) *common.Error {
-	productmodel, err := s.productRepository.GetProductByID(ctx, id)
+// UpdateProductPublication replaces the status and schedule of a product.
+func (s *ProductService) UpdateProductPublication(ctx context.Context, id uint, req *dto.UpdateProductPublicationRequest) (*model.Product, *common.Error) {
+	product, err := s.productRepository.GetProductDetailByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	product.Status = model.ProductStatus(req.Status)
+	product.PublishAt = req.PublishAt
+	product.UnpublishAt = req.UnpublishAt
+	if errPublication := product.CheckPublication(time.Now()); errPublication != nil {
+		return nil, common.ErrBadRequest(ctx).SetDetail(errPublication.Error())
+	}
+
+	if err := s.productRepository.UpdateProductPublication(ctx, product); err != nil {
+		return nil, err
+	}
+	s.schedulePublication(ctx, product)
+	return product, nil
+}
+
+// JobTypeProductPublication publishes and unpublishes products on schedule.
+// A job is queued for every publish and unpublish time, and each run catches
+// up with all products due by then, so a job left from a changed schedule
+// does no harm.
+const JobTypeProductPublication = "product_publication"
+
+type productPublicationPayload struct {
+	ProductID uint `json:"product_id"`
+}
+
+// schedulePublication queues the publication job for the upcoming times of
+// the product's schedule. The storefront already follows the schedule
+// itself, so failing to queue only leaves Status behind and is logged.
+func (s *ProductService) schedulePublication(ctx context.Context, product *model.Product) {
+	now := time.Now()
+	for _, at := range []*time.Time{product.PublishAt, product.UnpublishAt} {
+		if at == nil || !at.After(now) {
+			continue
+		}
+		payload := &productPublicationPayload{ProductID: product.ID}
+		if err := s.jobQueue.Enqueue(ctx, JobTypeProductPublication, payload, *at); err != nil {
+			log.Error(ctx, "schedulePublication: failed to schedule publication of product %d at %s: %v",
+				product.ID, at.Format(time.RFC3339), err)
+		}
+	}
+}
+
+// applyPublicationSchedule runs a JobTypeProductPublication job.
+func (s *ProductService) applyPublicationSchedule(ctx context.Context, job *model.Job) error {
+	published, unpublished, err := s.productRepository.ApplyPublicationSchedule(ctx, time.Now())
 	if err != nil {
 		return err
 	}
 
-	return s.productRepository.DeleteProduct(ctx, productmodel.ID)
+	if published > 0 || unpublished > 0 {
+		log.Info(ctx, "applyPublicationSchedule: published %d and unpublished %d products", published, unpublished)
+	}
+	return nil
+}
+
+// ListArchivedProducts lists the products in the trash.
+func (s *ProductService) ListArchivedProducts(ctx context.Context, pagination *dto.PaginationRequest) ([]*model.Product, int64, *common.Error) {
+	return s.productRepository.ListArchivedProducts(ctx, (pagination.Page-1)*pagination.Size, pagination.Size)
 }
 
 func (s *ProductService) AddProductVariant(ctx context.Context, req *dto.AddProductVariantRequest) *common.Error {
@@ -121,6 +402,7 @@ func (s *ProductService) AddProductVariant(ctx context.Context, req *dto.AddProd
 		Name:      req.Name,
 		Price:     req.Price,
 		Stock:     req.Stock,
+		Weight:    req.Weight,
 	}
 
 	existed, err := s.productRepository.IsExistProductVariant(ctx, req.ProductID, variantmodel.Name)
@@ -150,6 +432,9 @@ func (s *ProductService) UpdateProductVariant(ctx context.Context, req *dto.Upda
 	if req.Stock != nil {
 		variantmodel.Stock = *req.Stock
 	}
+	if req.Weight != nil {
+		variantmodel.Weight = *req.Weight
+	}
 
 	err = s.productRepository.UpdateProductVariant(ctx, variantmodel)
 	if err != nil {
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/config"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/client/zalo/payment"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/repositories"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+	"github.com/shopspring/decimal"
+)
+
+// ReconciliationService cross-checks what orders say was paid against the
+// bank transfers we received and the results Zalo holds.
+type ReconciliationService struct {
+	orderRepository           *repositories.OrderRepository
+	bankTransactionRepository *repositories.BankTransactionRepository
+	zaloPaymentClient         *payment.ZaloPaymentClient
+	cfg                       *config.Config
+}
+
+func NewReconciliationService(orderRepo *repositories.OrderRepository, bankTransactionRepo *repositories.BankTransactionRepository, zaloPaymentClient *payment.ZaloPaymentClient, cfg *config.Config) *ReconciliationService {
+	return &ReconciliationService{
+		orderRepository:           orderRepo,
+		bankTransactionRepository: bankTransactionRepo,
+		zaloPaymentClient:         zaloPaymentClient,
+		cfg:                       cfg,
+	}
+}
+
+// Reconcile reports the payment mismatches among orders created and bank
+// transfers received in the query's date range. Asking Zalo for the status of
+// every linked order is slow, so it only happens when the query asks for it.
+func (s *ReconciliationService) Reconcile(ctx context.Context, query *dto.ReconciliationQuery) (*dto.ReconciliationReport, *common.Error) {
+	from, err := parseDateBound(query.From, false)
+	if err != nil {
+		return nil, common.ErrBadRequest(ctx).SetDetail("from: " + err.Error())
+	}
+	to, err := parseDateBound(query.To, true)
+	if err != nil {
+		return nil, common.ErrBadRequest(ctx).SetDetail("to: " + err.Error())
+	}
+	if !to.After(*from) {
+		return nil, common.ErrBadRequest(ctx).SetDetail("to must be after from")
+	}
+
+	orders, errSvc := s.orderRepository.ListOrdersCreatedBetween(ctx, *from, *to)
+	if errSvc != nil {
+		return nil, errSvc
+	}
+	received, errSvc := s.bankTransactionRepository.ListBankTransactionsBetween(ctx, *from, *to)
+	if errSvc != nil {
+		return nil, errSvc
+	}
+	orderIDs := make([]string, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	applied, errSvc := s.bankTransactionRepository.ListBankTransactionsForOrders(ctx, orderIDs)
+	if errSvc != nil {
+		return nil, errSvc
+	}
+	txns := mergeBankTransactions(received, applied)
+
+	report := &dto.ReconciliationReport{
+		From:                    *from,
+		To:                      *to,
+		OrdersChecked:           len(orders),
+		BankTransactionsChecked: len(txns),
+		Mismatches:              []*dto.ReconciliationMismatch{},
+	}
+	report.Mismatches = append(report.Mismatches, reconcileOrderTransfers(orders, txns)...)
+	report.Mismatches = append(report.Mismatches, reconcileBankTransactions(txns)...)
+	if query.Zalo {
+		mismatches, checked := s.reconcileZaloOrders(ctx, orders)
+		report.Mismatches = append(report.Mismatches, mismatches...)
+		report.ZaloOrdersChecked = checked
+	}
+
+	return report, nil
+}
+
+// reconcileOrderTransfers compares each bank transfer order with the
+// transfers applied to it: an order taken as paid needs transfers, and the
+// transfers, paid amount and total should agree.
+func reconcileOrderTransfers(orders []*model.Order, txns []*model.BankTransaction) []*dto.ReconciliationMismatch {
+	byOrder := make(map[string][]*model.BankTransaction)
+	for _, txn := range txns {
+		if txn.OrderID != nil && txn.Status == model.BankTransactionStatusApplied {
+			byOrder[*txn.OrderID] = append(byOrder[*txn.OrderID], txn)
+		}
+	}
+
+	var mismatches []*dto.ReconciliationMismatch
+	for _, order := range orders {
+		if PaymentMethod(payment.BaseMethod(order.PaymentMethod)) != PaymentMethodBank {
+			continue
+		}
+
+		transferred := decimal.Zero
+		for _, txn := range byOrder[order.ID] {
+			transferred = transferred.Add(txn.Amount)
+		}
+		markedPaid := isOrderMarkedPaid(order)
+
+		switch {
+		case len(byOrder[order.ID]) == 0 && (markedPaid || order.PaidAmount.IsPositive()):
+			mismatches = append(mismatches, &dto.ReconciliationMismatch{
+				Type:     dto.MismatchMissingBankTransaction,
+				OrderID:  order.ID,
+				Expected: decimalPtr(order.TotalAmount),
+				Actual:   decimalPtr(order.PaidAmount),
+				Detail:   fmt.Sprintf("order is %s with %s paid but no bank transfer was applied", order.Status, order.PaidAmount.StringFixed(0)),
+			})
+			continue
+		case !transferred.Equal(order.PaidAmount):
+			mismatches = append(mismatches, &dto.ReconciliationMismatch{
+				Type:     dto.MismatchAmount,
+				OrderID:  order.ID,
+				Expected: decimalPtr(transferred),
+				Actual:   decimalPtr(order.PaidAmount),
+				Detail:   "paid amount differs from the bank transfers applied to the order",
+			})
+		}
+
+		if order.PaidAmount.GreaterThan(order.TotalAmount) || (markedPaid && order.PaidAmount.LessThan(order.TotalAmount)) {
+			mismatches = append(mismatches, &dto.ReconciliationMismatch{
+				Type:     dto.MismatchAmount,
+				OrderID:  order.ID,
+				Expected: decimalPtr(order.TotalAmount),
+				Actual:   decimalPtr(order.PaidAmount),
+				Detail:   fmt.Sprintf("order is %s and %s", order.Status, order.PaymentStatus),
+			})
+		}
+	}
+	return mismatches
+}
+
+// reconcileBankTransactions reports incoming transfers that reached no order
+// and transfers whose bank reference was seen more than once.
+func reconcileBankTransactions(txns []*model.BankTransaction) []*dto.ReconciliationMismatch {
+	var mismatches []*dto.ReconciliationMismatch
+	seen := make(map[string]*model.BankTransaction)
+	for _, txn := range txns {
+		if txn.ReferenceCode != "" {
+			if first, ok := seen[txn.ReferenceCode]; ok {
+				mismatches = append(mismatches, &dto.ReconciliationMismatch{
+					Type:              dto.MismatchDuplicateBankTransaction,
+					OrderID:           stringValue(txn.OrderID),
+					BankTransactionID: &txn.ID,
+					Actual:            decimalPtr(txn.Amount),
+					Detail:            fmt.Sprintf("reference %s was already received as bank transaction %d", txn.ReferenceCode, first.ID),
+				})
+			} else {
+				seen[txn.ReferenceCode] = txn
+			}
+		}
+
+		if strings.EqualFold(txn.TransferType, bankTransferIn) && txn.Status != model.BankTransactionStatusApplied {
+			detail := fmt.Sprintf("transfer %d is %s", txn.ProviderID, txn.Status)
+			if txn.Note != "" {
+				detail += ": " + txn.Note
+			}
+			mismatches = append(mismatches, &dto.ReconciliationMismatch{
+				Type:              dto.MismatchUnmatchedBankTransaction,
+				BankTransactionID: &txn.ID,
+				Actual:            decimalPtr(txn.Amount),
+				Detail:            detail,
+			})
+		}
+	}
+	return mismatches
+}
+
+// reconcileZaloOrders asks Zalo for the result of every order linked to a
+// Zalo order, except COD ones whose result we report ourselves, and compares
+// it with the order. It returns the mismatches and how many orders it asked
+// about.
+func (s *ReconciliationService) reconcileZaloOrders(ctx context.Context, orders []*model.Order) ([]*dto.ReconciliationMismatch, int) {
+	var mismatches []*dto.ReconciliationMismatch
+	checked := 0
+	for _, order := range orders {
+		if order.ZaloOrderID == nil || *order.ZaloOrderID == "" ||
+			PaymentMethod(payment.BaseMethod(order.PaymentMethod)) == PaymentMethodCod {
+			continue
+		}
+		checked++
+
+		mismatch := &dto.ReconciliationMismatch{
+			Type:        dto.MismatchZaloStatus,
+			OrderID:     order.ID,
+			ZaloOrderID: *order.ZaloOrderID,
+		}
+		status, err := s.zaloPaymentClient.GetOrderStatus(ctx, s.cfg, *order.ZaloOrderID)
+		switch {
+		case err != nil:
+			mismatch.Detail = fmt.Sprintf("could not get Zalo status: %v", err)
+		case status.Err != 0:
+			mismatch.Detail = fmt.Sprintf("Zalo returned error %d: %s", status.Err, status.Msg)
+		case status.Data.ReturnCode == 1 && !isOrderMarkedPaid(order):
+			mismatch.Detail = fmt.Sprintf("Zalo reports the order paid but it is %s", order.Status)
+		case status.Data.ReturnCode == -1 && isOrderMarkedPaid(order):
+			mismatch.Detail = fmt.Sprintf("Zalo reports the payment failed but the order is %s", order.Status)
+		case status.Data.ReturnCode == 1 && !decimal.NewFromInt(status.Data.Amount).Equal(order.TotalAmount):
+			mismatch.Type = dto.MismatchAmount
+			mismatch.Expected = decimalPtr(order.TotalAmount)
+			mismatch.Actual = decimalPtr(decimal.NewFromInt(status.Data.Amount))
+			mismatch.Detail = "Zalo amount differs from the order total"
+		default:
+			continue
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+	return mismatches, checked
+}
+
+// isOrderMarkedPaid reports whether the order went past waiting for payment,
+// which for prepaid methods means someone took it as paid.
+func isOrderMarkedPaid(order *model.Order) bool {
+	switch order.Status {
+	case model.OrderStatusPaying, model.OrderStatusFailed, model.OrderStatusCancelled:
+		return false
+	}
+	return true
+}
+
+// mergeBankTransactions joins two transaction lists without repeating a
+// transaction present in both.
+func mergeBankTransactions(lists ...[]*model.BankTransaction) []*model.BankTransaction {
+	seen := make(map[uint]bool)
+	var merged []*model.BankTransaction
+	for _, list := range lists {
+		for _, txn := range list {
+			if seen[txn.ID] {
+				continue
+			}
+			seen[txn.ID] = true
+			merged = append(merged, txn)
+		}
+	}
+	return merged
+}
+
+func decimalPtr(d decimal.Decimal) *decimal.Decimal {
+	return &d
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/repositories"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+)
+
+type ShippingService struct {
+	shippingRuleRepository *repositories.ShippingRuleRepository
+}
+
+func NewShippingService(shippingRuleRepo *repositories.ShippingRuleRepository) *ShippingService {
+	return &ShippingService{
+		shippingRuleRepository: shippingRuleRepo,
+	}
+}
+
+func (s *ShippingService) CreateShippingRule(ctx context.Context, req *dto.ShippingRuleRequest) (*model.ShippingRule, *common.Error) {
+	rule := &model.ShippingRule{}
+	req.Apply(rule)
+	if err := validateShippingRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	if err := s.shippingRuleRepository.CreateShippingRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *ShippingService) ListShippingRules(ctx context.Context) ([]model.ShippingRule, *common.Error) {
+	return s.shippingRuleRepository.ListShippingRules(ctx)
+}
+
+func (s *ShippingService) GetShippingRule(ctx context.Context, id uint) (*model.ShippingRule, *common.Error) {
+	return s.shippingRuleRepository.GetShippingRuleByID(ctx, id)
+}
+
+func (s *ShippingService) UpdateShippingRule(ctx context.Context, id uint, req *dto.ShippingRuleRequest) (*model.ShippingRule, *common.Error) {
+	rule, err := s.shippingRuleRepository.GetShippingRuleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	req.Apply(rule)
+	if err := validateShippingRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	if err := s.shippingRuleRepository.UpdateShippingRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *ShippingService) DeleteShippingRule(ctx context.Context, id uint) *common.Error {
+	return s.shippingRuleRepository.DeleteShippingRule(ctx, id)
+}
+
+// quote finds the rule for a parcel of weight grams to province and the fee it
+// charges for goods worth orderValue.
+func (s *ShippingService) quote(ctx context.Context, province string, weight, orderValue int64) (*model.ShippingRule, int64, *common.Error) {
+	rules, err := s.shippingRuleRepository.ListActiveShippingRules(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rule := model.SelectShippingRule(rules, province, weight)
+	if rule == nil {
+		return nil, 0, common.ErrBadRequest(ctx).SetDetail(fmt.Sprintf("no shipping available to %q for %d g", province, weight))
+	}
+	return rule, rule.FeeFor(weight, orderValue), nil
+}
+
+func validateShippingRule(ctx context.Context, rule *model.ShippingRule) *common.Error {
+	if rule.Name == "" {
+		return common.ErrBadRequest(ctx).SetDetail("name is required")
+	}
+	if rule.MaxWeight != nil && *rule.MaxWeight <= rule.MinWeight {
+		return common.ErrBadRequest(ctx).SetDetail("max_weight must be greater than min_weight")
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/repositories"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+	"github.com/shopspring/decimal"
+)
+
+type VoucherService struct {
+	voucherRepository *repositories.VoucherRepository
+}
+
+func NewVoucherService(voucherRepo *repositories.VoucherRepository) *VoucherService {
+	return &VoucherService{
+		voucherRepository: voucherRepo,
+	}
+}
+
+// voucherLine is an order line as seen by a voucher: what was bought and what
+// it costs before discount.
+type voucherLine struct {
+	ProductID  uint
+	CategoryID uint
+	Total      decimal.Decimal
+}
+
+func (s *VoucherService) CreateVoucher(ctx context.Context, req *dto.CreateVoucherRequest) (*model.Voucher, *common.Error) {
+	voucher := &model.Voucher{
+		Code:          normalizeVoucherCode(req.Code),
+		Description:   req.Description,
+		Type:          model.VoucherType(req.Type),
+		Value:         decimal.NewFromInt(req.Value),
+		MinOrderValue: decimal.NewFromInt(req.MinOrderValue),
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+		UsageLimit:    req.UsageLimit,
+		PerPhoneLimit: req.PerPhoneLimit,
+		Active:        true,
+		ProductIDs:    req.ProductIDs,
+		CategoryIDs:   req.CategoryIDs,
+	}
+	if req.MaxDiscount != nil {
+		maxDiscount := decimal.NewFromInt(*req.MaxDiscount)
+		voucher.MaxDiscount = &maxDiscount
+	}
+	if req.Active != nil {
+		voucher.Active = *req.Active
+	}
+
+	if err := s.validateVoucher(ctx, voucher); err != nil {
+		return nil, err
+	}
+	if err := s.voucherRepository.CreateVoucher(ctx, voucher); err != nil {
+		return nil, err
+	}
+	return voucher, nil
+}
+
+func (s *VoucherService) GetVoucher(ctx context.Context, id uint) (*dto.VoucherDetailResponse, *common.Error) {
+	voucher, err := s.voucherRepository.GetVoucherByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := s.voucherRepository.ListVoucherUsages(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.VoucherDetailResponse{Voucher: voucher, Usages: usages}, nil
+}
+
+func (s *VoucherService) ListVouchers(ctx context.Context, page, size int) ([]*model.Voucher, int64, *common.Error) {
+	return s.voucherRepository.ListVouchers(ctx, (page-1)*size, size)
+}
+
+// UpdateVoucher changes the given settings. A zero max discount, usage limit
+// or per-phone limit removes the limit.
+func (s *VoucherService) UpdateVoucher(ctx context.Context, id uint, req *dto.UpdateVoucherRequest) (*model.Voucher, *common.Error) {
+	voucher, err := s.voucherRepository.GetVoucherByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Code != nil {
+		voucher.Code = normalizeVoucherCode(*req.Code)
+	}
+	if req.Description != nil {
+		voucher.Description = *req.Description
+	}
+	if req.Type != nil {
+		voucher.Type = model.VoucherType(*req.Type)
+	}
+	if req.Value != nil {
+		voucher.Value = decimal.NewFromInt(*req.Value)
+	}
+	if req.MaxDiscount != nil {
+		voucher.MaxDiscount = nil
+		if *req.MaxDiscount > 0 {
+			maxDiscount := decimal.NewFromInt(*req.MaxDiscount)
+			voucher.MaxDiscount = &maxDiscount
+		}
+	}
+	if req.MinOrderValue != nil {
+		voucher.MinOrderValue = decimal.NewFromInt(*req.MinOrderValue)
+	}
+	if req.StartsAt != nil {
+		voucher.StartsAt = setOrNil(*req.StartsAt)
+	}
+	if req.EndsAt != nil {
+		voucher.EndsAt = setOrNil(*req.EndsAt)
+	}
+	if req.UsageLimit != nil {
+		voucher.UsageLimit = positiveOrNil(*req.UsageLimit)
+	}
+	if req.PerPhoneLimit != nil {
+		voucher.PerPhoneLimit = positiveOrNil(*req.PerPhoneLimit)
+	}
+	if req.Active != nil {
+		voucher.Active = *req.Active
+	}
+	if req.ProductIDs != nil {
+		voucher.ProductIDs = *req.ProductIDs
+	}
+	if req.CategoryIDs != nil {
+		voucher.CategoryIDs = *req.CategoryIDs
+	}
+
+	if err := s.validateVoucher(ctx, voucher); err != nil {
+		return nil, err
+	}
+	if err := s.voucherRepository.UpdateVoucher(ctx, voucher); err != nil {
+		return nil, err
+	}
+	return voucher, nil
+}
+
+func (s *VoucherService) DeleteVoucher(ctx context.Context, id uint) *common.Error {
+	return s.voucherRepository.DeleteVoucher(ctx, id)
+}
+
+// applyVoucher checks that the voucher with code can be used on an order made
+// of lines and returns it with the discount it gives. Usage limits are checked
+// again when the order is saved, under a lock on the voucher.
+func (s *VoucherService) applyVoucher(ctx context.Context, code string, lines []voucherLine) (*model.Voucher, decimal.Decimal, *common.Error) {
+	code = normalizeVoucherCode(code)
+	voucher, err := s.voucherRepository.GetVoucherByCode(ctx, code)
+	if err != nil {
+		if err.GetCode() == common.ErrorCodeNotFound {
+			return nil, decimal.Zero, common.ErrVoucherNotApplicable(ctx, code, "voucher does not exist")
+		}
+		return nil, decimal.Zero, err
+	}
+
+	if !voucher.IsActiveAt(time.Now()) {
+		return nil, decimal.Zero, common.ErrVoucherNotApplicable(ctx, voucher.Code, "voucher is not active")
+	}
+	if voucher.UsageLimit != nil && voucher.UsedCount >= *voucher.UsageLimit {
+		return nil, decimal.Zero, common.ErrVoucherNotApplicable(ctx, voucher.Code, "usage limit reached")
+	}
+
+	subtotal, eligible := decimal.Zero, decimal.Zero
+	for _, line := range lines {
+		subtotal = subtotal.Add(line.Total)
+		if voucher.Covers(line.ProductID, line.CategoryID) {
+			eligible = eligible.Add(line.Total)
+		}
+	}
+
+	if subtotal.LessThan(voucher.MinOrderValue) {
+		return nil, decimal.Zero, common.ErrVoucherNotApplicable(ctx, voucher.Code, "order total is below the minimum of "+voucher.MinOrderValue.StringFixed(0))
+	}
+	if eligible.IsZero() {
+		return nil, decimal.Zero, common.ErrVoucherNotApplicable(ctx, voucher.Code, "no item in the order is eligible")
+	}
+
+	return voucher, voucher.DiscountFor(eligible), nil
+}
+
+func (s *VoucherService) validateVoucher(ctx context.Context, voucher *model.Voucher) *common.Error {
+	if voucher.Code == "" {
+		return common.ErrBadRequest(ctx).SetDetail("code is required")
+	}
+	if voucher.Type == model.VoucherTypePercent && voucher.Value.GreaterThan(decimal.NewFromInt(100)) {
+		return common.ErrBadRequest(ctx).SetDetail("percent vouchers cannot exceed 100")
+	}
+	if voucher.StartsAt != nil && voucher.EndsAt != nil && !voucher.EndsAt.After(*voucher.StartsAt) {
+		return common.ErrBadRequest(ctx).SetDetail("ends_at must be after starts_at")
+	}
+
+	taken, err := s.voucherRepository.IsCodeTaken(ctx, voucher.Code, voucher.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return common.ErrConflict(ctx, "Voucher", "code already exists")
+	}
+	return nil
+}
+
+func normalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func positiveOrNil(n int) *int {
+	if n <= 0 {
+		return nil
+	}
+	return &n
+}
+
+func setOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/config"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/log"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/common/utils"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/client/zalo/payment"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/present/http/dto"
+)
+
+// checkoutMethods are the e-wallets Zalo Checkout takes payment through.
+var checkoutMethods = map[PaymentMethod]bool{
+	PaymentMethodZaloPay: true,
+	PaymentMethodMomo:    true,
+	PaymentMethodVnPay:   true,
+}
+
+// ZaloCheckoutProvider takes payments through the Zalo Mini App checkout. It
+// handles every method without a provider of its own, and the providers of
+// the methods Zalo settles with us build on it.
+type ZaloCheckoutProvider struct {
+	zaloPaymentClient *payment.ZaloPaymentClient
+	cfg               *config.Config
+}
+
+func NewZaloCheckoutProvider(zaloPaymentClient *payment.ZaloPaymentClient, cfg *config.Config) *ZaloCheckoutProvider {
+	return &ZaloCheckoutProvider{
+		zaloPaymentClient: zaloPaymentClient,
+		cfg:               cfg,
+	}
+}
+
+// Method is empty: Zalo Checkout is the fallback rather than the provider of
+// one method.
+func (p *ZaloCheckoutProvider) Method() PaymentMethod {
+	return ""
+}
+
+// InitialStatus keeps the order waiting for the customer to pay.
+func (p *ZaloCheckoutProvider) InitialStatus() model.OrderStatus {
+	return model.OrderStatusPaying
+}
+
+// Initiate signs the checkout parameters for the order's method.
+func (p *ZaloCheckoutProvider) Initiate(ctx context.Context, order *model.Order) (*PaymentInstructions, *common.Error) {
+	return &PaymentInstructions{
+		ZaloParams: p.checkoutParams(ctx, order),
+	}, nil
+}
+
+// checkoutParams builds the signed parameters the Mini App passes to Zalo's
+// createOrder. Every method we take is one Zalo provides, so none is custom.
+func (p *ZaloCheckoutProvider) checkoutParams(ctx context.Context, order *model.Order) *dto.ZaloOrderParams {
+	// Parameters: amount, desc, item, extradata, method
+	amount := order.TotalAmount.IntPart()
+	desc := order.ID
+
+	items := checkoutItems(order)
+	itemBytes, _ := json.Marshal(items)
+	itemStr := string(itemBytes)
+
+	// Extradata: {"pk_order_id": order.ID}
+	extraDataMap := map[string]interface{}{
+		"pk_order_id": order.ID,
+	}
+	extraDataBytes, _ := json.Marshal(extraDataMap)
+	extraDataStr := string(extraDataBytes)
+
+	methodMap := map[string]interface{}{
+		"id":       p.zaloPaymentClient.MethodID(order.PaymentMethod),
+		"isCustom": false,
+	}
+	methodBytes, _ := json.Marshal(methodMap)
+	methodStr := string(methodBytes)
+
+	// MAC Generation: sort keys -> key=value -> join & -> hmac
+	// Keys: amount, desc, extradata, item, method
+	// Note: value should be stringified if object, but here we prepared strings.
+	// doc: "Dữ liệu extradata và method phải có kiểu dữ liệu JSON String"
+
+	// Manual construction to ensure order
+	// Sorted keys: amount, desc, extradata, item, method
+	dataMac := fmt.Sprintf("amount=%d&desc=%s&extradata=%s&item=%s&method=%s",
+		amount, desc, extraDataStr, itemStr, methodStr)
+	log.Debug(ctx, "dataMac: %s", dataMac)
+
+	mac := utils.ComputeHmac256(dataMac, p.cfg.ZaloAppPrivateKey)
+	log.Debug(ctx, "mac: %s", mac)
+
+	return &dto.ZaloOrderParams{
+		Amount:    amount,
+		Desc:      desc,
+		Item:      itemStr,
+		Extradata: extraDataStr,
+		Method:    methodStr,
+		Mac:       mac,
+	}
+}
+
+// checkoutItem is a line of the item parameter of Zalo's createOrder, Amount
+// being the price of one unit.
+type checkoutItem struct {
+	ID       string `json:"id"`
+	Amount   int64  `json:"amount"`
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+// checkoutItems lists the order's items, and its shipping fee as a line of its
+// own, so they add up to the order total. The voucher discount is spread over
+// the item lines in proportion to their amounts, then over shipping, rather
+// than sent as a line with a negative amount. A line whose discounted amount
+// does not divide evenly by its quantity is split in two, the units of one
+// costing a dong more than those of the other.
+func checkoutItems(order *model.Order) []checkoutItem {
+	type line struct {
+		id, name string
+		total    int64
+		quantity int
+	}
+	var lines []line
+	var subtotal int64
+	for _, it := range order.OrderItems {
+		l := line{
+			id:       fmt.Sprintf("%d", it.ProductSnapshot.ProductID),
+			name:     it.ProductSnapshot.Name,
+			total:    it.Price.IntPart() * int64(it.Quantity),
+			quantity: it.Quantity,
+		}
+		subtotal += l.total
+		lines = append(lines, l)
+	}
+	if order.ShippingFee.IsPositive() {
+		lines = append(lines, line{id: "shipping", name: "Shipping fee", total: order.ShippingFee.IntPart(), quantity: 1})
+	}
+
+	discount := order.DiscountAmount.IntPart()
+	if discount > 0 && subtotal > 0 {
+		share := min(discount, subtotal)
+		left := share
+		for i := range order.OrderItems {
+			cut := share * lines[i].total / subtotal
+			lines[i].total -= cut
+			left -= cut
+		}
+		discount -= share - left
+	}
+	for i := range lines {
+		cut := min(discount, lines[i].total)
+		lines[i].total -= cut
+		discount -= cut
+	}
+
+	var items []checkoutItem
+	for _, l := range lines {
+		if l.quantity <= 0 {
+			continue
+		}
+		unit, extra := l.total/int64(l.quantity), int(l.total%int64(l.quantity))
+		if l.quantity > extra {
+			items = append(items, checkoutItem{ID: l.id, Amount: unit, Name: l.name, Quantity: l.quantity - extra})
+		}
+		if extra > 0 {
+			items = append(items, checkoutItem{ID: l.id, Amount: unit + 1, Name: l.name, Quantity: extra})
+		}
+	}
+	return items
+}
+
+// VerifyCallback checks the MAC of Zalo's notify and order callbacks. The
+// notify callback is signed with the app private key, the order callback with
+// the app secret.
+func (p *ZaloCheckoutProvider) VerifyCallback(ctx context.Context, callback any) bool {
+	var dataForMac, key, received string
+	switch req := callback.(type) {
+	case *dto.NofityCallbackRequest:
+		// data = 'appId={appId}&orderId={orderId}&method={method}'
+		dataForMac = fmt.Sprintf("appId=%s&orderId=%s&method=%s",
+			req.Data.AppID, req.Data.OrderID, req.Data.Method)
+		key, received = p.cfg.ZaloAppPrivateKey, req.Mac
+	case *dto.OrderCallbackRequest:
+		// dataForMac = "appId={appId}&amount={amount}&description={description}&orderId={orderId}&message={message}&resultCode={resultCode}&transId={transId}"
+		dataForMac = fmt.Sprintf("appId=%s&amount=%d&description=%s&orderId=%s&message=%s&resultCode=%d&transId=%s",
+			req.AppID, req.Amount, req.Description, req.OrderID, req.Message, req.ResultCode, req.TransID)
+		key, received = p.cfg.ZaloAppSecret, req.Mac
+	default:
+		log.Error(ctx, "VerifyCallback: unexpected callback %T", callback)
+		return false
+	}
+
+	mac := utils.ComputeHmac256(dataForMac, key)
+	if mac != received {
+		log.Debug(ctx, "dataForMac: %s", dataForMac)
+		log.Error(ctx, "Invalid MAC: calculated %s, received %s", mac, received)
+		return false
+	}
+	return true
+}
+
+// QueryStatus asks Zalo for the status of the order's Zalo order. The result
+// is final once Zalo reports the order paid or failed.
+func (p *ZaloCheckoutProvider) QueryStatus(ctx context.Context, order *model.Order) (*PaymentStatusResult, error) {
+	if order.ZaloOrderID == nil || *order.ZaloOrderID == "" {
+		return nil, fmt.Errorf("order %s has no Zalo order", order.ID)
+	}
+	zaloOrderID := *order.ZaloOrderID
+
+	orderStatus, err := p.zaloPaymentClient.GetOrderStatus(ctx, p.cfg, zaloOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status for %s: %w", zaloOrderID, err)
+	}
+	log.Debug(ctx, "QueryStatus: order %s status: %v", zaloOrderID, orderStatus)
+
+	if orderStatus.Err != 0 {
+		return nil, fmt.Errorf("zalo returned error %d for order %s", orderStatus.Err, zaloOrderID)
+	}
+
+	// ReturnCode 1 means paid, -1 failed; anything else is still in progress.
+	if orderStatus.Data.ReturnCode != 1 && orderStatus.Data.ReturnCode != -1 {
+		return &PaymentStatusResult{}, nil
+	}
+
+	raw, _ := json.Marshal(orderStatus.Data)
+	return &PaymentStatusResult{
+		Final: true,
+		Attempt: zaloPaymentResult(order, orderStatus.Data.Method, orderStatus.Data.Amount, orderStatus.Data.ReturnCode == 1,
+			orderStatus.Data.TransID, zaloOrderID, string(raw)),
+	}, nil
+}
+
+// ReportResult reports nothing: Zalo settles checkout payments itself and
+// takes no result for them.
+func (p *ZaloCheckoutProvider) ReportResult(ctx context.Context, order *model.Order, resultCode int) error {
+	return fmt.Errorf("%w: order %s is paid through zalo checkout", errResultNotSupported, order.ID)
+}
+
+// Refund reports nothing, for the same reason as ReportResult.
+func (p *ZaloCheckoutProvider) Refund(ctx context.Context, order *model.Order) error {
+	return p.ReportResult(ctx, order, payment.ResultCodeRefunded)
+}
+
+// sendOrderResult sends resultCode for the order through update, the Zalo
+// callback of the order's method. Orders never linked to a Zalo order have
+// nothing to report, which is an error wrapping errResultNotSupported. An
+// error wrapping errResultRejected means Zalo answered and refused the
+// result; any other error means Zalo could not be reached.
+func (p *ZaloCheckoutProvider) sendOrderResult(ctx context.Context, order *model.Order, resultCode int,
+	update func(context.Context, *payment.UpdateOrderStatusRequest) (*payment.UpdateOrderStatusResponse, error)) error {
+	if order.ZaloOrderID == nil || *order.ZaloOrderID == "" {
+		return fmt.Errorf("%w: order %s has no zalo order", errResultNotSupported, order.ID)
+	}
+
+	req := payment.NewUpdateOrderStatusRequest(p.cfg.ZaloAppID, *order.ZaloOrderID, resultCode, p.cfg.ZaloAppPrivateKey)
+	res, err := update(ctx, req)
+	if err != nil {
+		return err
+	}
+	if res.Error != 0 {
+		return fmt.Errorf("%w: result %d for order %s: %d %s",
+			errResultRejected, resultCode, order.ID, res.Error, res.Data.ReturnMessage)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/TruongHoang2004/ngoclam-zmp-backend/internal/infrastructure/persistence/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestCheckoutItems(t *testing.T) {
+	item := func(productID uint, price int64, quantity int) model.OrderItem {
+		return model.OrderItem{
+			Price:           decimal.NewFromInt(price),
+			Quantity:        quantity,
+			ProductSnapshot: &model.ProductSnapshot{ProductID: productID, Name: "Product"},
+		}
+	}
+	order := func(shipping, discount int64, items ...model.OrderItem) *model.Order {
+		var subtotal int64
+		for _, it := range items {
+			subtotal += it.Price.IntPart() * int64(it.Quantity)
+		}
+		return &model.Order{
+			OrderItems:     items,
+			ShippingFee:    decimal.NewFromInt(shipping),
+			DiscountAmount: decimal.NewFromInt(discount),
+			TotalAmount:    decimal.NewFromInt(subtotal + shipping - discount),
+		}
+	}
+
+	cases := []struct {
+		name  string
+		order *model.Order
+	}{
+		{"no discount", order(30000, 0, item(1, 100000, 2), item(2, 55000, 1))},
+		{"uneven discount", order(30000, 10001, item(1, 100000, 3), item(2, 33333, 7))},
+		{"discount over the items", order(30000, 60000, item(1, 25000, 2))},
+		{"free order", order(0, 99999, item(1, 33333, 3))},
+	}
+
+	for _, c := range cases {
+		var sum int64
+		for _, it := range checkoutItems(c.order) {
+			if it.Amount < 0 {
+				t.Errorf("%s: item %s amount = %d; want non-negative", c.name, it.ID, it.Amount)
+			}
+			sum += it.Amount * int64(it.Quantity)
+		}
+		if want := c.order.TotalAmount.IntPart(); sum != want {
+			t.Errorf("%s: items sum to %d; want %d", c.name, sum, want)
+		}
+	}
+}